@@ -0,0 +1,247 @@
+// Package accounts is a client for Duo's Accounts API, with which a parent
+// account, such as a managed service provider's, manages its child
+// accounts.
+// See https://duo.com/docs/accountsapi
+package accounts
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	duoapi "github.com/duosecurity/duo_api_golang"
+	"github.com/duosecurity/duo_api_golang/admin"
+)
+
+// ErrNoAPIHostname is returned for a child account whose APIHostname is
+// empty, e.g. one built from just an ID, since its calls could not be sent.
+// Accounts returned by ListAccounts and CreateAccount have it set.
+var ErrNoAPIHostname = errors.New("accounts: the child account has no API hostname")
+
+// Client provides access to Duo's Accounts API.  It must be built with the
+// keys of an Accounts API application in the parent account.
+type Client struct {
+	duoapi.DuoApi
+}
+
+// New initializes an Accounts API Client struct.  Copies of a DuoApi share
+// its state, so clients built from one DuoApi share it too.
+func New(base duoapi.DuoApi) *Client {
+	return &Client{base}
+}
+
+// FromDuoApi returns a Client which makes its calls with a copy of api,
+// sharing its state.
+//
+// Example: accounts.FromDuoApi(duoapi.NewDuoApi(ikey,skey,host,userAgent,duoapi.SetTimeout(10*time.Second)))
+func FromDuoApi(api *duoapi.DuoApi) *Client {
+	return &Client{*api}
+}
+
+// Account models a child account.
+type Account struct {
+	AccountID   string `json:"account_id"`
+	Name        string `json:"name"`
+	APIHostname string `json:"api_hostname"`
+}
+
+// Editions of Duo a child account may have.
+const (
+	EditionEnterprise = "ENTERPRISE"
+	EditionPlatform   = "PLATFORM"
+	EditionBeyond     = "BEYOND"
+)
+
+// ListAccountsResult models responses containing a list of child accounts.
+type ListAccountsResult struct {
+	duoapi.StatResult
+	Response []Account
+}
+
+// AccountResult models responses containing a single child account.
+type AccountResult struct {
+	duoapi.StatResult
+	Response Account
+}
+
+// EditionResult models responses containing a child account's edition.
+type EditionResult struct {
+	duoapi.StatResult
+	Response struct {
+		Edition string `json:"edition"`
+	}
+}
+
+// TelephonyCreditsResult models responses containing a child account's
+// telephony credits.
+type TelephonyCreditsResult struct {
+	duoapi.StatResult
+	Response struct {
+		Credits int `json:"credits"`
+	}
+}
+
+// Admin returns an Admin API client for account, which calls the child
+// account's API hostname with the parent's keys and the account's
+// account_id, so that any admin.Client method acts on the child account.
+// It fails with ErrNoAPIHostname if account.APIHostname is empty.
+//
+// Example: childAdmin, err := client.Admin(account)
+func (c *Client) Admin(account Account) (*admin.Client, error) {
+	api, err := c.child(account)
+	if err != nil {
+		return nil, err
+	}
+	return admin.FromDuoApi(api), nil
+}
+
+func (c *Client) child(account Account) (*duoapi.DuoApi, error) {
+	if account.APIHostname == "" {
+		return nil, ErrNoAPIHostname
+	}
+	return c.WithHost(account.APIHostname).WithParams(url.Values{"account_id": []string{account.AccountID}}), nil
+}
+
+// ListAccounts calls POST /accounts/v1/account/list
+// See https://duo.com/docs/accountsapi#retrieve-accounts
+func (c *Client) ListAccounts() (*ListAccountsResult, error) {
+	return c.ListAccountsContext(context.Background())
+}
+
+// ListAccountsContext is like ListAccounts, but takes a context that bounds the request.
+func (c *Client) ListAccountsContext(ctx context.Context) (*ListAccountsResult, error) {
+	result := &ListAccountsResult{}
+	err := c.SignedCallInto(ctx, http.MethodPost, "/accounts/v1/account/list", nil, result, duoapi.UseTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CreateAccount calls POST /accounts/v1/account/create
+// See https://duo.com/docs/accountsapi#create-account
+func (c *Client) CreateAccount(name string) (*AccountResult, error) {
+	return c.CreateAccountContext(context.Background(), name)
+}
+
+// CreateAccountContext is like CreateAccount, but takes a context that bounds the request.
+func (c *Client) CreateAccountContext(ctx context.Context, name string) (*AccountResult, error) {
+	params := url.Values{}
+	params.Set("name", name)
+
+	result := &AccountResult{}
+	err := c.SignedCallInto(ctx, http.MethodPost, "/accounts/v1/account/create", params, result, duoapi.UseTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteAccount calls POST /accounts/v1/account/delete
+// See https://duo.com/docs/accountsapi#delete-account
+func (c *Client) DeleteAccount(accountID string) (*duoapi.StatResult, error) {
+	return c.DeleteAccountContext(context.Background(), accountID)
+}
+
+// DeleteAccountContext is like DeleteAccount, but takes a context that bounds the request.
+func (c *Client) DeleteAccountContext(ctx context.Context, accountID string) (*duoapi.StatResult, error) {
+	params := url.Values{}
+	params.Set("account_id", accountID)
+
+	result := &duoapi.StatResult{}
+	err := c.SignedCallInto(ctx, http.MethodPost, "/accounts/v1/account/delete", params, result, duoapi.UseTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetEdition calls GET /admin/v1/billing/edition for account.
+// See https://duo.com/docs/accountsapi#get-edition
+func (c *Client) GetEdition(account Account) (*EditionResult, error) {
+	return c.GetEditionContext(context.Background(), account)
+}
+
+// GetEditionContext is like GetEdition, but takes a context that bounds the request.
+func (c *Client) GetEditionContext(ctx context.Context, account Account) (*EditionResult, error) {
+	api, err := c.child(account)
+	if err != nil {
+		return nil, err
+	}
+	result := &EditionResult{}
+	err = api.SignedCallInto(ctx, http.MethodGet, "/admin/v1/billing/edition", nil, result, duoapi.UseTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SetEdition calls POST /admin/v1/billing/edition for account.
+// See https://duo.com/docs/accountsapi#set-edition
+func (c *Client) SetEdition(account Account, edition string) (*duoapi.StatResult, error) {
+	return c.SetEditionContext(context.Background(), account, edition)
+}
+
+// SetEditionContext is like SetEdition, but takes a context that bounds the request.
+func (c *Client) SetEditionContext(ctx context.Context, account Account, edition string) (*duoapi.StatResult, error) {
+	params := url.Values{}
+	params.Set("edition", edition)
+
+	api, err := c.child(account)
+	if err != nil {
+		return nil, err
+	}
+	result := &duoapi.StatResult{}
+	err = api.SignedCallInto(ctx, http.MethodPost, "/admin/v1/billing/edition", params, result, duoapi.UseTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetTelephonyCredits calls GET /admin/v1/billing/telephony_credits for account.
+// See https://duo.com/docs/accountsapi#get-telephony-credits
+func (c *Client) GetTelephonyCredits(account Account) (*TelephonyCreditsResult, error) {
+	return c.GetTelephonyCreditsContext(context.Background(), account)
+}
+
+// GetTelephonyCreditsContext is like GetTelephonyCredits, but takes a context that bounds the request.
+func (c *Client) GetTelephonyCreditsContext(ctx context.Context, account Account) (*TelephonyCreditsResult, error) {
+	api, err := c.child(account)
+	if err != nil {
+		return nil, err
+	}
+	result := &TelephonyCreditsResult{}
+	err = api.SignedCallInto(ctx, http.MethodGet, "/admin/v1/billing/telephony_credits", nil, result, duoapi.UseTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SetTelephonyCredits calls POST /admin/v1/billing/telephony_credits for
+// account, setting its balance to credits, which are taken from or returned
+// to the parent account.
+// See https://duo.com/docs/accountsapi#set-telephony-credits
+func (c *Client) SetTelephonyCredits(account Account, credits int) (*TelephonyCreditsResult, error) {
+	return c.SetTelephonyCreditsContext(context.Background(), account, credits)
+}
+
+// SetTelephonyCreditsContext is like SetTelephonyCredits, but takes a context that bounds the request.
+func (c *Client) SetTelephonyCreditsContext(ctx context.Context, account Account, credits int) (*TelephonyCreditsResult, error) {
+	params := url.Values{}
+	params.Set("credits", strconv.Itoa(credits))
+
+	api, err := c.child(account)
+	if err != nil {
+		return nil, err
+	}
+	result := &TelephonyCreditsResult{}
+	err = api.SignedCallInto(ctx, http.MethodPost, "/admin/v1/billing/telephony_credits", params, result, duoapi.UseTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,146 @@
+package accounts
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	duoapi "github.com/duosecurity/duo_api_golang"
+)
+
+const (
+	parentHost = "api-parent.duosecurity.com"
+	childHost  = "api-child.duosecurity.com"
+)
+
+var child = Account{AccountID: "DA9VZOC44FH0J8TNV9FS", Name: "Child", APIHostname: childHost}
+
+// newAccountsServer returns a server which verifies that Accounts API calls
+// are signed for the parent's host, and Admin API calls for the child's,
+// before answering with responses.
+func newAccountsServer(t *testing.T, responses map[string]string) *httptest.Server {
+	lookup := func(ctx context.Context, ikey string) (string, error) {
+		if ikey != "DIPARENT" {
+			return "", duoapi.ErrUnknownIKey
+		}
+		return "parent-secret", nil
+	}
+	parent := &duoapi.Verifier{Lookup: lookup, Host: parentHost}
+	childVerifier := &duoapi.Verifier{Lookup: lookup, Host: childHost}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := parent
+		if strings.HasPrefix(r.URL.Path, "/admin/") {
+			v = childVerifier
+			if r.FormValue("account_id") != child.AccountID {
+				t.Errorf("%s: expected the child's account_id, but got %q", r.URL.Path, r.FormValue("account_id"))
+			}
+		}
+		if _, err := v.Verify(r); err != nil {
+			t.Errorf("%s: %v", r.URL.Path, err)
+		}
+		response, ok := responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"stat": "OK", "response": %s}`, response)
+	}))
+}
+
+func buildAccountsClient(t *testing.T, ts *httptest.Server) *Client {
+	base, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return FromDuoApi(duoapi.NewDuoApi("DIPARENT", "parent-secret", parentHost, "GoTestClient", duoapi.SetBaseURL(base)))
+}
+
+func TestAccounts(t *testing.T) {
+	ts := newAccountsServer(t, map[string]string{
+		"POST /accounts/v1/account/list":   `[{"account_id": "DA9VZOC44FH0J8TNV9FS", "name": "Child", "api_hostname": "api-child.duosecurity.com"}]`,
+		"POST /accounts/v1/account/create": `{"account_id": "DA9VZOC44FH0J8TNV9FS", "name": "Child", "api_hostname": "api-child.duosecurity.com"}`,
+		"POST /accounts/v1/account/delete": `""`,
+	})
+	defer ts.Close()
+	client := buildAccountsClient(t, ts)
+
+	list, err := client.ListAccounts()
+	if err != nil {
+		t.Fatalf("Unexpected error from ListAccounts: %v", err)
+	}
+	if len(list.Response) != 1 || list.Response[0] != child {
+		t.Errorf("Unexpected accounts %+v", list.Response)
+	}
+	created, err := client.CreateAccount("Child")
+	if err != nil || created.Response != child {
+		t.Errorf("Unexpected CreateAccount result %+v, %v", created, err)
+	}
+	if _, err := client.DeleteAccount(child.AccountID); err != nil {
+		t.Errorf("Unexpected error from DeleteAccount: %v", err)
+	}
+}
+
+func TestChildAccountBilling(t *testing.T) {
+	ts := newAccountsServer(t, map[string]string{
+		"GET /admin/v1/billing/edition":            `{"edition": "ENTERPRISE"}`,
+		"POST /admin/v1/billing/edition":           `""`,
+		"GET /admin/v1/billing/telephony_credits":  `{"credits": 100}`,
+		"POST /admin/v1/billing/telephony_credits": `{"credits": 200}`,
+	})
+	defer ts.Close()
+	client := buildAccountsClient(t, ts)
+
+	edition, err := client.GetEdition(child)
+	if err != nil || edition.Response.Edition != EditionEnterprise {
+		t.Errorf("Unexpected GetEdition result %+v, %v", edition, err)
+	}
+	if _, err := client.SetEdition(child, EditionPlatform); err != nil {
+		t.Errorf("Unexpected error from SetEdition: %v", err)
+	}
+	credits, err := client.GetTelephonyCredits(child)
+	if err != nil || credits.Response.Credits != 100 {
+		t.Errorf("Unexpected GetTelephonyCredits result %+v, %v", credits, err)
+	}
+	credits, err = client.SetTelephonyCredits(child, 200)
+	if err != nil || credits.Response.Credits != 200 {
+		t.Errorf("Unexpected SetTelephonyCredits result %+v, %v", credits, err)
+	}
+}
+
+func TestChildAccountAdmin(t *testing.T) {
+	ts := newAccountsServer(t, map[string]string{
+		"GET /admin/v1/users/DU3RP9I2WOC59VZX672N": `{"user_id": "DU3RP9I2WOC59VZX672N", "username": "jsmith"}`,
+	})
+	defer ts.Close()
+	client := buildAccountsClient(t, ts)
+
+	childAdmin, err := client.Admin(child)
+	if err != nil {
+		t.Fatalf("Unexpected error from Admin: %v", err)
+	}
+	user, err := childAdmin.GetUser("DU3RP9I2WOC59VZX672N")
+	if err != nil {
+		t.Fatalf("Unexpected error from GetUser: %v", err)
+	}
+	if user.Response.Username != "jsmith" {
+		t.Errorf("Unexpected user %+v", user.Response)
+	}
+}
+
+func TestChildAccountWithoutHostname(t *testing.T) {
+	ts := newAccountsServer(t, map[string]string{})
+	defer ts.Close()
+	client := buildAccountsClient(t, ts)
+
+	account := Account{AccountID: child.AccountID}
+	if _, err := client.Admin(account); err != ErrNoAPIHostname {
+		t.Errorf("Expected ErrNoAPIHostname from Admin, but got %v", err)
+	}
+	if _, err := client.GetEdition(account); err != ErrNoAPIHostname {
+		t.Errorf("Expected ErrNoAPIHostname from GetEdition, but got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package accounts
+
+import (
+	"context"
+	"sync"
+
+	"github.com/duosecurity/duo_api_golang/admin"
+)
+
+// DefaultParallelism is the number of accounts FanOut calls at once when
+// it is given a parallelism of zero or less.
+const DefaultParallelism = 4
+
+// AdminFunc is an Admin API operation, such as a call to an admin.Client
+// method, run against one child account by FanOut.
+type AdminFunc func(ctx context.Context, client *admin.Client) (interface{}, error)
+
+// FanOutResult is the outcome of an AdminFunc for one account.
+type FanOutResult struct {
+	Account Account
+	// Result is what the AdminFunc returned, e.g. an
+	// *admin.GetUsersResult, and Err its error.
+	Result interface{}
+	Err    error
+}
+
+// FanOutResults are the outcomes of a FanOut, keyed by account ID, so
+// there is one result for each distinct account.
+type FanOutResults map[string]FanOutResult
+
+// Errors returns the errors among results, keyed by account ID, or nil if
+// every account succeeded.
+func (results FanOutResults) Errors() map[string]error {
+	var errs map[string]error
+	for id, result := range results {
+		if result.Err != nil {
+			if errs == nil {
+				errs = make(map[string]error)
+			}
+			errs[id] = result.Err
+		}
+	}
+	return errs
+}
+
+// FanOut runs fn against the Admin API of each of accounts concurrently,
+// for at most parallelism accounts at a time.  An account's failure is
+// recorded in its result rather than stopping the others.  Accounts which
+// have not been started when ctx is done fail with ctx's error, without
+// fn being called.  An account listed more than once is only run once.
+//
+// Example:
+//
+//	results := client.FanOut(ctx, accounts, 8, func(ctx context.Context, c *admin.Client) (interface{}, error) {
+//		return c.GetUsersContext(ctx, admin.GetUsersUsername("jsmith"))
+//	})
+func (c *Client) FanOut(ctx context.Context, accounts []Account, parallelism int, fn AdminFunc) FanOutResults {
+	if parallelism <= 0 {
+		parallelism = DefaultParallelism
+	}
+
+	results := make(FanOutResults, len(accounts))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, parallelism)
+	seen := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		if seen[account.AccountID] {
+			continue
+		}
+		seen[account.AccountID] = true
+
+		result := FanOutResult{Account: account}
+		// select picks at random when a slot is free and ctx is done too,
+		// so check ctx first.
+		started := false
+		if ctx.Err() == nil {
+			select {
+			case sem <- struct{}{}:
+				started = ctx.Err() == nil
+				if !started {
+					<-sem
+				}
+			case <-ctx.Done():
+			}
+		}
+		if !started {
+			result.Err = ctx.Err()
+			mu.Lock()
+			results[account.AccountID] = result
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(result FanOutResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			var client *admin.Client
+			client, result.Err = c.Admin(result.Account)
+			if result.Err == nil {
+				result.Result, result.Err = fn(ctx, client)
+			}
+			mu.Lock()
+			results[result.Account.AccountID] = result
+			mu.Unlock()
+		}(result)
+	}
+	wg.Wait()
+	return results
+}
+
+// FanOutAll is like FanOut, but runs fn against every child account.  It
+// fails only if the accounts cannot be listed.
+func (c *Client) FanOutAll(ctx context.Context, parallelism int, fn AdminFunc) (FanOutResults, error) {
+	list, err := c.ListAccountsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.FanOut(ctx, list.Response, parallelism, fn), nil
+}
@@ -0,0 +1,118 @@
+package accounts
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	duoapi "github.com/duosecurity/duo_api_golang"
+	"github.com/duosecurity/duo_api_golang/admin"
+)
+
+func TestFanOutAll(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/accounts/v1/account/list" {
+			fmt.Fprint(w, `{"stat": "OK", "response": [
+				{"account_id": "DA1", "api_hostname": "api-1.duosecurity.com"},
+				{"account_id": "DA2", "api_hostname": "api-2.duosecurity.com"},
+				{"account_id": "DA3", "api_hostname": "api-3.duosecurity.com"},
+				{"account_id": "DA4", "api_hostname": "api-4.duosecurity.com"},
+				{"account_id": "DAFAIL", "api_hostname": "api-5.duosecurity.com"}
+			]}`)
+			return
+		}
+
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		accountID := r.FormValue("account_id")
+		if accountID == "DAFAIL" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"stat": "FAIL", "code": 40401, "message": "Resource not found"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"stat": "OK", "response": {"user_count": %d}}`, len(accountID))
+	}))
+	defer ts.Close()
+	client := buildAccountsClient(t, ts)
+
+	results, err := client.FanOutAll(context.Background(), 2, func(ctx context.Context, c *admin.Client) (interface{}, error) {
+		return c.GetAccountInfoSummaryContext(ctx)
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("Expected a result for each account, but got %+v", results)
+	}
+	if maxInFlight > 2 {
+		t.Errorf("Expected at most 2 concurrent calls, but saw %d", maxInFlight)
+	}
+	for _, id := range []string{"DA1", "DA2", "DA3", "DA4"} {
+		summary, ok := results[id].Result.(*admin.GetAccountInfoSummaryResult)
+		if !ok || results[id].Err != nil || summary.Response.UserCount != 3 {
+			t.Errorf("%s: unexpected result %+v", id, results[id])
+		}
+	}
+	errs := results.Errors()
+	if len(errs) != 1 || !duoapi.IsNotFound(errs["DAFAIL"]) {
+		t.Errorf("Expected DAFAIL to be not found, but got %v", errs)
+	}
+}
+
+func TestFanOutCanceled(t *testing.T) {
+	client := FromDuoApi(duoapi.NewDuoApi("DIPARENT", "parent-secret", parentHost, "GoTestClient"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	accounts := []Account{
+		{AccountID: "DA1", APIHostname: childHost},
+		{AccountID: "DA2", APIHostname: childHost},
+		{AccountID: "DA3", APIHostname: childHost},
+	}
+	var calls int32
+	results := client.FanOut(ctx, accounts, 1, func(ctx context.Context, c *admin.Client) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, nil
+	})
+	for _, account := range accounts {
+		if results[account.AccountID].Err != context.Canceled {
+			t.Errorf("%s: expected context.Canceled, but got %+v", account.AccountID, results[account.AccountID])
+		}
+	}
+	if calls != 0 {
+		t.Errorf("Expected no account to be started after cancellation, but %d were", calls)
+	}
+}
+
+func TestFanOutDuplicateAccounts(t *testing.T) {
+	client := FromDuoApi(duoapi.NewDuoApi("DIPARENT", "parent-secret", parentHost, "GoTestClient"))
+
+	accounts := []Account{
+		{AccountID: "DA1", APIHostname: childHost},
+		{AccountID: "DA1", APIHostname: childHost},
+		{AccountID: "DA2", APIHostname: childHost},
+	}
+	var calls int32
+	results := client.FanOut(context.Background(), accounts, 2, func(ctx context.Context, c *admin.Client) (interface{}, error) {
+		return atomic.AddInt32(&calls, 1), nil
+	})
+	if calls != 2 || len(results) != 2 {
+		t.Errorf("Expected each account to run once, but got %d calls and %+v", calls, results)
+	}
+}
@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -30,11 +31,20 @@ func (l *ListResult) metadata() ListResultMetadata {
 	return l.Metadata
 }
 
-// New initializes an admin API Client struct.
+// New initializes an admin API Client struct.  Copies of a DuoApi share
+// its state, so clients built from one DuoApi share it too.
 func New(base duoapi.DuoApi) *Client {
 	return &Client{base}
 }
 
+// FromDuoApi returns a Client which makes its calls with a copy of api,
+// sharing its state.
+//
+// Example: admin.FromDuoApi(duoapi.NewDuoApi(ikey,skey,host,userAgent,duoapi.SetTimeout(10*time.Second)))
+func FromDuoApi(api *duoapi.DuoApi) *Client {
+	return &Client{*api}
+}
+
 // User models a single user.
 type User struct {
 	Alias1            *string           `json:"alias1" url:"alias1"`
@@ -73,8 +83,9 @@ func (u *User) URLValues() url.Values {
 		if tag == "" {
 			continue
 		}
-		// Skip fields have a zero value.
-		if v.Field(i).Interface() == reflect.Zero(v.Field(i).Type()).Interface() {
+		// Skip fields have a zero value.  IsZero, unlike comparing with
+		// reflect.Zero, does not panic on the map-typed Aliases.
+		if v.Field(i).IsZero() {
 			continue
 		}
 		var val string
@@ -240,13 +251,18 @@ func (result *GetUsersResult) appendResponse(users interface{}) {
 // GetUsers calls GET /admin/v1/users
 // See https://duo.com/docs/adminapi#retrieve-users
 func (c *Client) GetUsers(options ...func(*url.Values)) (*GetUsersResult, error) {
+	return c.GetUsersContext(context.Background(), options...)
+}
+
+// GetUsersContext is like GetUsers, but takes a context that bounds the request.
+func (c *Client) GetUsersContext(ctx context.Context, options ...func(*url.Values)) (*GetUsersResult, error) {
 	params := url.Values{}
 	for _, o := range options {
 		o(&params)
 	}
 
 	cb := func(params url.Values) (responsePage, error) {
-		return c.retrieveUsers(params)
+		return c.retrieveUsers(ctx, params)
 	}
 	response, err := c.retrieveItems(params, cb)
 	if err != nil {
@@ -296,32 +312,69 @@ func (c *Client) retrieveItems(
 	return fetcher(params)
 }
 
-func (c *Client) retrieveUsers(params url.Values) (*GetUsersResult, error) {
-	_, body, err := c.SignedCall(http.MethodGet, "/admin/v1/users", params, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
-
+func (c *Client) retrieveUsers(ctx context.Context, params url.Values) (*GetUsersResult, error) {
 	result := &GetUsersResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, "/admin/v1/users", params, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
+// EachUser calls fn with each user, fetching a page at a time and decoding
+// each page as it is read, so that a large user list is never held in
+// memory at once.  The limit option sets the page size.  It stops at the
+// first error, from a call or from fn, and returns it.
+// Calls GET /admin/v1/users
+// See https://duo.com/docs/adminapi#retrieve-users
+func (c *Client) EachUser(fn func(User) error, options ...func(*url.Values)) error {
+	return c.EachUserContext(context.Background(), fn, options...)
+}
+
+// EachUserContext is like EachUser, but takes a context that bounds the requests.
+func (c *Client) EachUserContext(ctx context.Context, fn func(User) error, options ...func(*url.Values)) error {
+	params := url.Values{}
+	for _, o := range options {
+		o(&params)
+	}
+	if params.Get("offset") == "" {
+		params.Set("offset", "0")
+	}
+	if params.Get("limit") == "" {
+		params.Set("limit", "100")
+	}
+
+	for {
+		result := &GetUsersResult{}
+		err := c.SignedCallInto(ctx, http.MethodGet, "/admin/v1/users", params, result, duoapi.UseTimeout, duoapi.StreamResponse)
+		if err != nil {
+			return err
+		}
+		for _, user := range result.Response {
+			if err := fn(user); err != nil {
+				return err
+			}
+		}
+		next := result.Metadata.NextOffset.String()
+		if next == "" {
+			return nil
+		}
+		params.Set("offset", next)
+	}
+}
+
 // GetUser calls GET /admin/v1/users/:user_id
 // See https://duo.com/docs/adminapi#retrieve-user-by-id
 func (c *Client) GetUser(userID string) (*GetUserResult, error) {
-	path := fmt.Sprintf("/admin/v1/users/%s", userID)
+	return c.GetUserContext(context.Background(), userID)
+}
 
-	_, body, err := c.SignedCall(http.MethodGet, path, nil, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
+// GetUserContext is like GetUser, but takes a context that bounds the request.
+func (c *Client) GetUserContext(ctx context.Context, userID string) (*GetUserResult, error) {
+	path := fmt.Sprintf("/admin/v1/users/%s", userID)
 
 	result := &GetUserResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, path, nil, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -331,15 +384,15 @@ func (c *Client) GetUser(userID string) (*GetUserResult, error) {
 // CreateUser calls POST /admin/v1/users
 // See https://duo.com/docs/adminapi#create-user
 func (c *Client) CreateUser(params url.Values) (*GetUserResult, error) {
-	path := "/admin/v1/users"
+	return c.CreateUserContext(context.Background(), params)
+}
 
-	_, body, err := c.SignedCall(http.MethodPost, path, params, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
+// CreateUserContext is like CreateUser, but takes a context that bounds the request.
+func (c *Client) CreateUserContext(ctx context.Context, params url.Values) (*GetUserResult, error) {
+	path := "/admin/v1/users"
 
 	result := &GetUserResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodPost, path, params, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -349,15 +402,15 @@ func (c *Client) CreateUser(params url.Values) (*GetUserResult, error) {
 // ModifyUser calls POST /admin/v1/users/:user_id
 // See https://duo.com/docs/adminapi#modify-user
 func (c *Client) ModifyUser(userID string, params url.Values) (*GetUserResult, error) {
-	path := fmt.Sprintf("/admin/v1/users/%s", userID)
+	return c.ModifyUserContext(context.Background(), userID, params)
+}
 
-	_, body, err := c.SignedCall(http.MethodPost, path, params, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
+// ModifyUserContext is like ModifyUser, but takes a context that bounds the request.
+func (c *Client) ModifyUserContext(ctx context.Context, userID string, params url.Values) (*GetUserResult, error) {
+	path := fmt.Sprintf("/admin/v1/users/%s", userID)
 
 	result := &GetUserResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodPost, path, params, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -367,15 +420,15 @@ func (c *Client) ModifyUser(userID string, params url.Values) (*GetUserResult, e
 // DeleteUser calls DELETE /admin/v1/users/:user_id
 // See https://duo.com/docs/adminapi#delete-user
 func (c *Client) DeleteUser(userID string) (*duoapi.StatResult, error) {
-	path := fmt.Sprintf("/admin/v1/users/%s", userID)
+	return c.DeleteUserContext(context.Background(), userID)
+}
 
-	_, body, err := c.SignedCall(http.MethodDelete, path, nil, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
+// DeleteUserContext is like DeleteUser, but takes a context that bounds the request.
+func (c *Client) DeleteUserContext(ctx context.Context, userID string) (*duoapi.StatResult, error) {
+	path := fmt.Sprintf("/admin/v1/users/%s", userID)
 
 	result := &duoapi.StatResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodDelete, path, nil, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -385,13 +438,18 @@ func (c *Client) DeleteUser(userID string) (*duoapi.StatResult, error) {
 // GetUserGroups calls GET /admin/v1/users/:user_id/groups
 // See https://duo.com/docs/adminapi#retrieve-groups-by-user-id
 func (c *Client) GetUserGroups(userID string, options ...func(*url.Values)) (*GetGroupsResult, error) {
+	return c.GetUserGroupsContext(context.Background(), userID, options...)
+}
+
+// GetUserGroupsContext is like GetUserGroups, but takes a context that bounds the request.
+func (c *Client) GetUserGroupsContext(ctx context.Context, userID string, options ...func(*url.Values)) (*GetGroupsResult, error) {
 	params := url.Values{}
 	for _, o := range options {
 		o(&params)
 	}
 
 	cb := func(params url.Values) (responsePage, error) {
-		return c.retrieveUserGroups(userID, params)
+		return c.retrieveUserGroups(ctx, userID, params)
 	}
 	response, err := c.retrieveItems(params, cb)
 	if err != nil {
@@ -404,18 +462,18 @@ func (c *Client) GetUserGroups(userID string, options ...func(*url.Values)) (*Ge
 // AssociateGroupWithUser calls POST /admin/v1/users/:user_id/groups
 // See https://duo.com/docs/adminapi#associate-group-with-user
 func (c *Client) AssociateGroupWithUser(userID string, groupID string) (*duoapi.StatResult, error) {
+	return c.AssociateGroupWithUserContext(context.Background(), userID, groupID)
+}
+
+// AssociateGroupWithUserContext is like AssociateGroupWithUser, but takes a context that bounds the request.
+func (c *Client) AssociateGroupWithUserContext(ctx context.Context, userID string, groupID string) (*duoapi.StatResult, error) {
 	path := fmt.Sprintf("/admin/v1/users/%s/groups", userID)
 
 	params := url.Values{}
 	params.Set("group_id", groupID)
 
-	_, body, err := c.SignedCall(http.MethodPost, path, params, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
-
 	result := &duoapi.StatResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodPost, path, params, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -425,31 +483,26 @@ func (c *Client) AssociateGroupWithUser(userID string, groupID string) (*duoapi.
 // DisassociateGroupFromUser calls POST /admin/v1/users/:user_id/groups
 // See https://duo.com/docs/adminapi#disassociate-group-from-user
 func (c *Client) DisassociateGroupFromUser(userID string, groupID string) (*duoapi.StatResult, error) {
-	path := fmt.Sprintf("/admin/v1/users/%s/groups/%s", userID, groupID)
+	return c.DisassociateGroupFromUserContext(context.Background(), userID, groupID)
+}
 
-	_, body, err := c.SignedCall(http.MethodDelete, path, nil, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
+// DisassociateGroupFromUserContext is like DisassociateGroupFromUser, but takes a context that bounds the request.
+func (c *Client) DisassociateGroupFromUserContext(ctx context.Context, userID string, groupID string) (*duoapi.StatResult, error) {
+	path := fmt.Sprintf("/admin/v1/users/%s/groups/%s", userID, groupID)
 
 	result := &duoapi.StatResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodDelete, path, nil, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
-func (c *Client) retrieveUserGroups(userID string, params url.Values) (*GetGroupsResult, error) {
+func (c *Client) retrieveUserGroups(ctx context.Context, userID string, params url.Values) (*GetGroupsResult, error) {
 	path := fmt.Sprintf("/admin/v1/users/%s/groups", userID)
 
-	_, body, err := c.SignedCall(http.MethodGet, path, params, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
-
 	result := &GetGroupsResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, path, params, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -459,13 +512,18 @@ func (c *Client) retrieveUserGroups(userID string, params url.Values) (*GetGroup
 // GetUserPhones calls GET /admin/v1/users/:user_id/phones
 // See https://duo.com/docs/adminapi#retrieve-phones-by-user-id
 func (c *Client) GetUserPhones(userID string, options ...func(*url.Values)) (*GetPhonesResult, error) {
+	return c.GetUserPhonesContext(context.Background(), userID, options...)
+}
+
+// GetUserPhonesContext is like GetUserPhones, but takes a context that bounds the request.
+func (c *Client) GetUserPhonesContext(ctx context.Context, userID string, options ...func(*url.Values)) (*GetPhonesResult, error) {
 	params := url.Values{}
 	for _, o := range options {
 		o(&params)
 	}
 
 	cb := func(params url.Values) (responsePage, error) {
-		return c.retrieveUserPhones(userID, params)
+		return c.retrieveUserPhones(ctx, userID, params)
 	}
 	response, err := c.retrieveItems(params, cb)
 	if err != nil {
@@ -475,16 +533,11 @@ func (c *Client) GetUserPhones(userID string, options ...func(*url.Values)) (*Ge
 	return response.(*GetPhonesResult), nil
 }
 
-func (c *Client) retrieveUserPhones(userID string, params url.Values) (*GetPhonesResult, error) {
+func (c *Client) retrieveUserPhones(ctx context.Context, userID string, params url.Values) (*GetPhonesResult, error) {
 	path := fmt.Sprintf("/admin/v1/users/%s/phones", userID)
 
-	_, body, err := c.SignedCall(http.MethodGet, path, params, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
-
 	result := &GetPhonesResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, path, params, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -494,13 +547,18 @@ func (c *Client) retrieveUserPhones(userID string, params url.Values) (*GetPhone
 // GetUserTokens calls GET /admin/v1/users/:user_id/tokens
 // See https://duo.com/docs/adminapi#retrieve-hardware-tokens-by-user-id
 func (c *Client) GetUserTokens(userID string, options ...func(*url.Values)) (*GetTokensResult, error) {
+	return c.GetUserTokensContext(context.Background(), userID, options...)
+}
+
+// GetUserTokensContext is like GetUserTokens, but takes a context that bounds the request.
+func (c *Client) GetUserTokensContext(ctx context.Context, userID string, options ...func(*url.Values)) (*GetTokensResult, error) {
 	params := url.Values{}
 	for _, o := range options {
 		o(&params)
 	}
 
 	cb := func(params url.Values) (responsePage, error) {
-		return c.retrieveUserTokens(userID, params)
+		return c.retrieveUserTokens(ctx, userID, params)
 	}
 	response, err := c.retrieveItems(params, cb)
 	if err != nil {
@@ -510,16 +568,11 @@ func (c *Client) GetUserTokens(userID string, options ...func(*url.Values)) (*Ge
 	return response.(*GetTokensResult), nil
 }
 
-func (c *Client) retrieveUserTokens(userID string, params url.Values) (*GetTokensResult, error) {
+func (c *Client) retrieveUserTokens(ctx context.Context, userID string, params url.Values) (*GetTokensResult, error) {
 	path := fmt.Sprintf("/admin/v1/users/%s/tokens", userID)
 
-	_, body, err := c.SignedCall(http.MethodGet, path, params, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
-
 	result := &GetTokensResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, path, params, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -535,18 +588,18 @@ type StringResult struct {
 // AssociateUserToken calls POST /admin/v1/users/:user_id/tokens
 // See https://duo.com/docs/adminapi#associate-hardware-token-with-user
 func (c *Client) AssociateUserToken(userID, tokenID string) (*StringResult, error) {
+	return c.AssociateUserTokenContext(context.Background(), userID, tokenID)
+}
+
+// AssociateUserTokenContext is like AssociateUserToken, but takes a context that bounds the request.
+func (c *Client) AssociateUserTokenContext(ctx context.Context, userID, tokenID string) (*StringResult, error) {
 	path := fmt.Sprintf("/admin/v1/users/%s/tokens", userID)
 
 	params := url.Values{}
 	params.Set("token_id", tokenID)
 
-	_, body, err := c.SignedCall(http.MethodPost, path, params, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
-
 	result := &StringResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodPost, path, params, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -556,13 +609,18 @@ func (c *Client) AssociateUserToken(userID, tokenID string) (*StringResult, erro
 // GetUserU2FTokens calls GET /admin/v1/users/:user_id/u2ftokens
 // See https://duo.com/docs/adminapi#retrieve-u2f-tokens-by-user-id
 func (c *Client) GetUserU2FTokens(userID string, options ...func(*url.Values)) (*GetU2FTokensResult, error) {
+	return c.GetUserU2FTokensContext(context.Background(), userID, options...)
+}
+
+// GetUserU2FTokensContext is like GetUserU2FTokens, but takes a context that bounds the request.
+func (c *Client) GetUserU2FTokensContext(ctx context.Context, userID string, options ...func(*url.Values)) (*GetU2FTokensResult, error) {
 	params := url.Values{}
 	for _, o := range options {
 		o(&params)
 	}
 
 	cb := func(params url.Values) (responsePage, error) {
-		return c.retrieveUserU2FTokens(userID, params)
+		return c.retrieveUserU2FTokens(ctx, userID, params)
 	}
 	response, err := c.retrieveItems(params, cb)
 	if err != nil {
@@ -572,16 +630,11 @@ func (c *Client) GetUserU2FTokens(userID string, options ...func(*url.Values)) (
 	return response.(*GetU2FTokensResult), nil
 }
 
-func (c *Client) retrieveUserU2FTokens(userID string, params url.Values) (*GetU2FTokensResult, error) {
+func (c *Client) retrieveUserU2FTokens(ctx context.Context, userID string, params url.Values) (*GetU2FTokensResult, error) {
 	path := fmt.Sprintf("/admin/v1/users/%s/u2ftokens", userID)
 
-	_, body, err := c.SignedCall(http.MethodGet, path, params, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
-
 	result := &GetU2FTokensResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, path, params, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -597,6 +650,11 @@ type StringArrayResult struct {
 // GetUserBypassCodes calls POST /admin/v1/users/:user_id/bypass_codes
 // see https://duo.com/docs/adminapi#create-bypass-codes-for-user
 func (c *Client) GetUserBypassCodes(userID string, options ...func(*url.Values)) (*StringArrayResult, error) {
+	return c.GetUserBypassCodesContext(context.Background(), userID, options...)
+}
+
+// GetUserBypassCodesContext is like GetUserBypassCodes, but takes a context that bounds the request.
+func (c *Client) GetUserBypassCodesContext(ctx context.Context, userID string, options ...func(*url.Values)) (*StringArrayResult, error) {
 	path := fmt.Sprintf("/admin/v1/users/%s/bypass_codes", userID)
 
 	params := url.Values{}
@@ -604,13 +662,8 @@ func (c *Client) GetUserBypassCodes(userID string, options ...func(*url.Values))
 		o(&params)
 	}
 
-	_, body, err := c.SignedCall(http.MethodPost, path, params, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
-
 	result := &StringArrayResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodPost, path, params, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -639,13 +692,18 @@ func (result *GetGroupsResult) appendResponse(groups interface{}) {
 // GetGroups calls GET /admin/v1/groups
 // See https://duo.com/docs/adminapi#retrieve-groups
 func (c *Client) GetGroups(options ...func(*url.Values)) (*GetGroupsResult, error) {
+	return c.GetGroupsContext(context.Background(), options...)
+}
+
+// GetGroupsContext is like GetGroups, but takes a context that bounds the request.
+func (c *Client) GetGroupsContext(ctx context.Context, options ...func(*url.Values)) (*GetGroupsResult, error) {
 	params := url.Values{}
 	for _, o := range options {
 		o(&params)
 	}
 
 	cb := func(params url.Values) (responsePage, error) {
-		return c.retrieveGroups(params)
+		return c.retrieveGroups(ctx, params)
 	}
 	response, err := c.retrieveItems(params, cb)
 	if err != nil {
@@ -655,14 +713,9 @@ func (c *Client) GetGroups(options ...func(*url.Values)) (*GetGroupsResult, erro
 	return response.(*GetGroupsResult), nil
 }
 
-func (c *Client) retrieveGroups(params url.Values) (*GetGroupsResult, error) {
-	_, body, err := c.SignedCall(http.MethodGet, "/admin/v1/groups", params, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
-
+func (c *Client) retrieveGroups(ctx context.Context, params url.Values) (*GetGroupsResult, error) {
 	result := &GetGroupsResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, "/admin/v1/groups", params, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -678,15 +731,15 @@ type GetGroupResult struct {
 // GetGroup calls GET /admin/v2/group/:group_id
 // See https://duo.com/docs/adminapi#get-group-info
 func (c *Client) GetGroup(groupID string) (*GetGroupResult, error) {
-	path := fmt.Sprintf("/admin/v2/groups/%s", groupID)
+	return c.GetGroupContext(context.Background(), groupID)
+}
 
-	_, body, err := c.SignedCall(http.MethodGet, path, nil, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
+// GetGroupContext is like GetGroup, but takes a context that bounds the request.
+func (c *Client) GetGroupContext(ctx context.Context, groupID string) (*GetGroupResult, error) {
+	path := fmt.Sprintf("/admin/v2/groups/%s", groupID)
 
 	result := &GetGroupResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, path, nil, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -728,13 +781,18 @@ func (result *GetPhonesResult) appendResponse(phones interface{}) {
 // GetPhones calls GET /admin/v1/phones
 // See https://duo.com/docs/adminapi#phones
 func (c *Client) GetPhones(options ...func(*url.Values)) (*GetPhonesResult, error) {
+	return c.GetPhonesContext(context.Background(), options...)
+}
+
+// GetPhonesContext is like GetPhones, but takes a context that bounds the request.
+func (c *Client) GetPhonesContext(ctx context.Context, options ...func(*url.Values)) (*GetPhonesResult, error) {
 	params := url.Values{}
 	for _, o := range options {
 		o(&params)
 	}
 
 	cb := func(params url.Values) (responsePage, error) {
-		return c.retrievePhones(params)
+		return c.retrievePhones(ctx, params)
 	}
 	response, err := c.retrieveItems(params, cb)
 	if err != nil {
@@ -744,14 +802,9 @@ func (c *Client) GetPhones(options ...func(*url.Values)) (*GetPhonesResult, erro
 	return response.(*GetPhonesResult), nil
 }
 
-func (c *Client) retrievePhones(params url.Values) (*GetPhonesResult, error) {
-	_, body, err := c.SignedCall(http.MethodGet, "/admin/v1/phones", params, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
-
+func (c *Client) retrievePhones(ctx context.Context, params url.Values) (*GetPhonesResult, error) {
 	result := &GetPhonesResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, "/admin/v1/phones", params, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -767,15 +820,15 @@ type GetPhoneResult struct {
 // GetPhone calls GET /admin/v1/phones/:phone_id
 // See https://duo.com/docs/adminapi#retrieve-phone-by-id
 func (c *Client) GetPhone(phoneID string) (*GetPhoneResult, error) {
-	path := fmt.Sprintf("/admin/v1/phones/%s", phoneID)
+	return c.GetPhoneContext(context.Background(), phoneID)
+}
 
-	_, body, err := c.SignedCall(http.MethodGet, path, nil, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
+// GetPhoneContext is like GetPhone, but takes a context that bounds the request.
+func (c *Client) GetPhoneContext(ctx context.Context, phoneID string) (*GetPhoneResult, error) {
+	path := fmt.Sprintf("/admin/v1/phones/%s", phoneID)
 
 	result := &GetPhoneResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, path, nil, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -785,15 +838,15 @@ func (c *Client) GetPhone(phoneID string) (*GetPhoneResult, error) {
 // DeletePhone calls DELETE /admin/v1/phones/:phone_id
 // See https://duo.com/docs/adminapi#delete-phone
 func (c *Client) DeletePhone(phoneID string) (*duoapi.StatResult, error) {
-	path := fmt.Sprintf("/admin/v1/phones/%s", phoneID)
+	return c.DeletePhoneContext(context.Background(), phoneID)
+}
 
-	_, body, err := c.SignedCall(http.MethodDelete, path, nil, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
+// DeletePhoneContext is like DeletePhone, but takes a context that bounds the request.
+func (c *Client) DeletePhoneContext(ctx context.Context, phoneID string) (*duoapi.StatResult, error) {
+	path := fmt.Sprintf("/admin/v1/phones/%s", phoneID)
 
 	result := &duoapi.StatResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodDelete, path, nil, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -829,13 +882,18 @@ func (result *GetTokensResult) appendResponse(tokens interface{}) {
 // GetTokens calls GET /admin/v1/tokens
 // See https://duo.com/docs/adminapi#retrieve-hardware-tokens
 func (c *Client) GetTokens(options ...func(*url.Values)) (*GetTokensResult, error) {
+	return c.GetTokensContext(context.Background(), options...)
+}
+
+// GetTokensContext is like GetTokens, but takes a context that bounds the request.
+func (c *Client) GetTokensContext(ctx context.Context, options ...func(*url.Values)) (*GetTokensResult, error) {
 	params := url.Values{}
 	for _, o := range options {
 		o(&params)
 	}
 
 	cb := func(params url.Values) (responsePage, error) {
-		return c.retrieveTokens(params)
+		return c.retrieveTokens(ctx, params)
 	}
 	response, err := c.retrieveItems(params, cb)
 	if err != nil {
@@ -845,14 +903,9 @@ func (c *Client) GetTokens(options ...func(*url.Values)) (*GetTokensResult, erro
 	return response.(*GetTokensResult), nil
 }
 
-func (c *Client) retrieveTokens(params url.Values) (*GetTokensResult, error) {
-	_, body, err := c.SignedCall(http.MethodGet, "/admin/v1/tokens", params, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
-
+func (c *Client) retrieveTokens(ctx context.Context, params url.Values) (*GetTokensResult, error) {
 	result := &GetTokensResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, "/admin/v1/tokens", params, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -868,15 +921,15 @@ type GetTokenResult struct {
 // GetToken calls GET /admin/v1/tokens/:token_id
 // See https://duo.com/docs/adminapi#retrieve-hardware-tokens
 func (c *Client) GetToken(tokenID string) (*GetTokenResult, error) {
-	path := fmt.Sprintf("/admin/v1/tokens/%s", tokenID)
+	return c.GetTokenContext(context.Background(), tokenID)
+}
 
-	_, body, err := c.SignedCall(http.MethodGet, path, nil, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
+// GetTokenContext is like GetToken, but takes a context that bounds the request.
+func (c *Client) GetTokenContext(ctx context.Context, tokenID string) (*GetTokenResult, error) {
+	path := fmt.Sprintf("/admin/v1/tokens/%s", tokenID)
 
 	result := &GetTokenResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, path, nil, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -904,13 +957,18 @@ func (result *GetU2FTokensResult) appendResponse(tokens interface{}) {
 // GetU2FTokens calls GET /admin/v1/u2ftokens
 // See https://duo.com/docs/adminapi#retrieve-u2f-tokens
 func (c *Client) GetU2FTokens(options ...func(*url.Values)) (*GetU2FTokensResult, error) {
+	return c.GetU2FTokensContext(context.Background(), options...)
+}
+
+// GetU2FTokensContext is like GetU2FTokens, but takes a context that bounds the request.
+func (c *Client) GetU2FTokensContext(ctx context.Context, options ...func(*url.Values)) (*GetU2FTokensResult, error) {
 	params := url.Values{}
 	for _, o := range options {
 		o(&params)
 	}
 
 	cb := func(params url.Values) (responsePage, error) {
-		return c.retrieveU2FTokens(params)
+		return c.retrieveU2FTokens(ctx, params)
 	}
 	response, err := c.retrieveItems(params, cb)
 	if err != nil {
@@ -920,14 +978,9 @@ func (c *Client) GetU2FTokens(options ...func(*url.Values)) (*GetU2FTokensResult
 	return response.(*GetU2FTokensResult), nil
 }
 
-func (c *Client) retrieveU2FTokens(params url.Values) (*GetU2FTokensResult, error) {
-	_, body, err := c.SignedCall(http.MethodGet, "/admin/v1/u2ftokens", params, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
-
+func (c *Client) retrieveU2FTokens(ctx context.Context, params url.Values) (*GetU2FTokensResult, error) {
 	result := &GetU2FTokensResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, "/admin/v1/u2ftokens", params, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -937,15 +990,15 @@ func (c *Client) retrieveU2FTokens(params url.Values) (*GetU2FTokensResult, erro
 // GetU2FToken calls GET /admin/v1/u2ftokens/:registration_id
 // See https://duo.com/docs/adminapi#retrieve-u2f-token-by-id
 func (c *Client) GetU2FToken(registrationID string) (*GetU2FTokensResult, error) {
-	path := fmt.Sprintf("/admin/v1/u2ftokens/%s", registrationID)
+	return c.GetU2FTokenContext(context.Background(), registrationID)
+}
 
-	_, body, err := c.SignedCall(http.MethodGet, path, nil, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
+// GetU2FTokenContext is like GetU2FToken, but takes a context that bounds the request.
+func (c *Client) GetU2FTokenContext(ctx context.Context, registrationID string) (*GetU2FTokensResult, error) {
+	path := fmt.Sprintf("/admin/v1/u2ftokens/%s", registrationID)
 
 	result := &GetU2FTokensResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, path, nil, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -973,13 +1026,18 @@ func (result *GetIntegrationsResult) appendResponse(integrations interface{}) {
 // GetIntegrations calls GET /admin/v1/integrations
 // See https://duo.com/docs/adminapi#retrieve-integrations
 func (c *Client) GetIntegrations(options ...func(*url.Values)) (*GetIntegrationsResult, error) {
+	return c.GetIntegrationsContext(context.Background(), options...)
+}
+
+// GetIntegrationsContext is like GetIntegrations, but takes a context that bounds the request.
+func (c *Client) GetIntegrationsContext(ctx context.Context, options ...func(*url.Values)) (*GetIntegrationsResult, error) {
 	params := url.Values{}
 	for _, o := range options {
 		o(&params)
 	}
 
 	cb := func(params url.Values) (responsePage, error) {
-		return c.retrieveIntegrations(params)
+		return c.retrieveIntegrations(ctx, params)
 	}
 	response, err := c.retrieveItems(params, cb)
 	if err != nil {
@@ -989,14 +1047,9 @@ func (c *Client) GetIntegrations(options ...func(*url.Values)) (*GetIntegrations
 	return response.(*GetIntegrationsResult), nil
 }
 
-func (c *Client) retrieveIntegrations(params url.Values) (*GetIntegrationsResult, error) {
-	_, body, err := c.SignedCall(http.MethodGet, "/admin/v1/integrations", params, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
-
+func (c *Client) retrieveIntegrations(ctx context.Context, params url.Values) (*GetIntegrationsResult, error) {
 	result := &GetIntegrationsResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, "/admin/v1/integrations", params, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -1012,15 +1065,15 @@ type GetIntegrationResult struct {
 // GetIntegration calls GET /admin/v1/integrations/:integration_key
 // See https://duo.com/docs/adminapi#retrieve-integration-by-integration-key
 func (c *Client) GetIntegration(integrationKey string) (*GetIntegrationResult, error) {
-	path := fmt.Sprintf("/admin/v1/integrations/%s", integrationKey)
+	return c.GetIntegrationContext(context.Background(), integrationKey)
+}
 
-	_, body, err := c.SignedCall(http.MethodGet, path, nil, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
+// GetIntegrationContext is like GetIntegration, but takes a context that bounds the request.
+func (c *Client) GetIntegrationContext(ctx context.Context, integrationKey string) (*GetIntegrationResult, error) {
+	path := fmt.Sprintf("/admin/v1/integrations/%s", integrationKey)
 
 	result := &GetIntegrationResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, path, nil, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -1048,13 +1101,18 @@ func (result *GetAdministratorsResult) appendResponse(administrators interface{}
 // GetAdministrators calls GET /admin/v1/administrators
 // See https://duo.com/docs/adminapi#retrieve-administrators
 func (c *Client) GetAdministrators(options ...func(*url.Values)) (*GetAdministratorsResult, error) {
+	return c.GetAdministratorsContext(context.Background(), options...)
+}
+
+// GetAdministratorsContext is like GetAdministrators, but takes a context that bounds the request.
+func (c *Client) GetAdministratorsContext(ctx context.Context, options ...func(*url.Values)) (*GetAdministratorsResult, error) {
 	params := url.Values{}
 	for _, o := range options {
 		o(&params)
 	}
 
 	cb := func(params url.Values) (responsePage, error) {
-		return c.retrieveAdministrators(params)
+		return c.retrieveAdministrators(ctx, params)
 	}
 	response, err := c.retrieveItems(params, cb)
 	if err != nil {
@@ -1064,14 +1122,9 @@ func (c *Client) GetAdministrators(options ...func(*url.Values)) (*GetAdministra
 	return response.(*GetAdministratorsResult), nil
 }
 
-func (c *Client) retrieveAdministrators(params url.Values) (*GetAdministratorsResult, error) {
-	_, body, err := c.SignedCall(http.MethodGet, "/admin/v1/admins", params, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
-
+func (c *Client) retrieveAdministrators(ctx context.Context, params url.Values) (*GetAdministratorsResult, error) {
 	result := &GetAdministratorsResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, "/admin/v1/admins", params, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -1087,15 +1140,15 @@ type GetAdministratorResult struct {
 // GetAdministrator calls GET /admin/v1/administrators/:admin_id
 // See https://duo.com/docs/adminapi#retrieve-administrator-by-id
 func (c *Client) GetAdministrator(administratorID string) (*GetAdministratorResult, error) {
-	path := fmt.Sprintf("/admin/v1/admins/%s", administratorID)
+	return c.GetAdministratorContext(context.Background(), administratorID)
+}
 
-	_, body, err := c.SignedCall(http.MethodGet, path, nil, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
+// GetAdministratorContext is like GetAdministrator, but takes a context that bounds the request.
+func (c *Client) GetAdministratorContext(ctx context.Context, administratorID string) (*GetAdministratorResult, error) {
+	path := fmt.Sprintf("/admin/v1/admins/%s", administratorID)
 
 	result := &GetAdministratorResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, path, nil, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -1123,13 +1176,18 @@ func (result *GetAdministrativeUnitsResult) appendResponse(administrativeUnits i
 // GetAdministrativeUnits calls GET /admin/v1/administrative_units
 // See https://duo.com/docs/adminapi#retrieve-administrative-units
 func (c *Client) GetAdministrativeUnits(options ...func(*url.Values)) (*GetAdministrativeUnitsResult, error) {
+	return c.GetAdministrativeUnitsContext(context.Background(), options...)
+}
+
+// GetAdministrativeUnitsContext is like GetAdministrativeUnits, but takes a context that bounds the request.
+func (c *Client) GetAdministrativeUnitsContext(ctx context.Context, options ...func(*url.Values)) (*GetAdministrativeUnitsResult, error) {
 	params := url.Values{}
 	for _, o := range options {
 		o(&params)
 	}
 
 	cb := func(params url.Values) (responsePage, error) {
-		return c.retrieveAdministrativeUnits(params)
+		return c.retrieveAdministrativeUnits(ctx, params)
 	}
 	response, err := c.retrieveItems(params, cb)
 	if err != nil {
@@ -1139,14 +1197,9 @@ func (c *Client) GetAdministrativeUnits(options ...func(*url.Values)) (*GetAdmin
 	return response.(*GetAdministrativeUnitsResult), nil
 }
 
-func (c *Client) retrieveAdministrativeUnits(params url.Values) (*GetAdministrativeUnitsResult, error) {
-	_, body, err := c.SignedCall(http.MethodGet, "/admin/v1/administrative_units", params, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
-
+func (c *Client) retrieveAdministrativeUnits(ctx context.Context, params url.Values) (*GetAdministrativeUnitsResult, error) {
 	result := &GetAdministrativeUnitsResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, "/admin/v1/administrative_units", params, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -1162,15 +1215,15 @@ type GetAdministrativeUnitResult struct {
 // GetAdministrativeUnits calls GET /admin/v1/administrative_units/[admin_unit_id]
 // See https://duo.com/docs/adminapi#retrieve-administrative-unit-details
 func (c *Client) GetAdministrativeUnit(administrativeUnitID string) (*GetAdministrativeUnitResult, error) {
-	path := fmt.Sprintf("/admin/v1/administrative_units/%s", administrativeUnitID)
+	return c.GetAdministrativeUnitContext(context.Background(), administrativeUnitID)
+}
 
-	_, body, err := c.SignedCall(http.MethodGet, path, nil, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
+// GetAdministrativeUnitContext is like GetAdministrativeUnit, but takes a context that bounds the request.
+func (c *Client) GetAdministrativeUnitContext(ctx context.Context, administrativeUnitID string) (*GetAdministrativeUnitResult, error) {
+	path := fmt.Sprintf("/admin/v1/administrative_units/%s", administrativeUnitID)
 
 	result := &GetAdministrativeUnitResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, path, nil, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -1196,15 +1249,15 @@ type GetAccountInfoSummaryResult struct {
 // GetAccountInfoSummary calls GET /admin/v1/info/summary
 // See https://duo.com/docs/adminapi#retrieve-summary
 func (c *Client) GetAccountInfoSummary() (*GetAccountInfoSummaryResult, error) {
-	path := fmt.Sprintf("/admin/v1/info/summary")
+	return c.GetAccountInfoSummaryContext(context.Background())
+}
 
-	_, body, err := c.SignedCall(http.MethodGet, path, nil, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
+// GetAccountInfoSummaryContext is like GetAccountInfoSummary, but takes a context that bounds the request.
+func (c *Client) GetAccountInfoSummaryContext(ctx context.Context) (*GetAccountInfoSummaryResult, error) {
+	path := fmt.Sprintf("/admin/v1/info/summary")
 
 	result := &GetAccountInfoSummaryResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, path, nil, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -1251,15 +1304,15 @@ type GetAccountSettingsResult struct {
 // GetAccountInfoSummary calls GET /admin/v1/info/summary
 // See https://duo.com/docs/adminapi#retrieve-summary
 func (c *Client) GetAccountSettings() (*GetAccountSettingsResult, error) {
-	path := fmt.Sprintf("/admin/v1/settings")
+	return c.GetAccountSettingsContext(context.Background())
+}
 
-	_, body, err := c.SignedCall(http.MethodGet, path, nil, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
+// GetAccountSettingsContext is like GetAccountSettings, but takes a context that bounds the request.
+func (c *Client) GetAccountSettingsContext(ctx context.Context) (*GetAccountSettingsResult, error) {
+	path := fmt.Sprintf("/admin/v1/settings")
 
 	result := &GetAccountSettingsResult{}
-	err = json.Unmarshal(body, result)
+	err := c.SignedCallInto(ctx, http.MethodGet, path, nil, result, duoapi.UseTimeout)
 	if err != nil {
 		return nil, err
 	}
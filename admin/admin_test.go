@@ -1,6 +1,8 @@
 package admin
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -234,6 +236,16 @@ func TestUser_URLValues(t *testing.T) {
 	}
 }
 
+// User has a map field, Aliases, which URLValues must skip when it is nil
+// rather than compare, since maps are not comparable.
+func TestUser_URLValuesMapField(t *testing.T) {
+	u := &User{Username: "jsmith"}
+	want := url.Values{"username": {"jsmith"}}
+	if got := u.URLValues(); !reflect.DeepEqual(got, want) {
+		t.Errorf("User.URLValues() = %v, want %v", got, want)
+	}
+}
+
 func TestGetUsers(t *testing.T) {
 	var last_request *http.Request
 	ts := httptest.NewTLSServer(
@@ -423,6 +435,9 @@ func TestDeleteUser(t *testing.T) {
 	if result.Stat != "OK" {
 		t.Errorf("Expected OK, but got %s", result.Stat)
 	}
+	if result.Meta.StatusCode != http.StatusOK || result.Meta.Date.IsZero() {
+		t.Errorf("Expected the response's meta, but got %+v", result.Meta)
+	}
 }
 
 const getUsersPage1Response = `{
@@ -568,6 +583,156 @@ func TestGetUsersMultipage(t *testing.T) {
 	}
 }
 
+func TestEachUser(t *testing.T) {
+	requests := []*http.Request{}
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(requests) == 0 {
+				fmt.Fprintln(w, getUsersPage1Response)
+			} else {
+				fmt.Fprintln(w, getUsersPage2Response)
+			}
+			requests = append(requests, r)
+		}),
+	)
+	defer ts.Close()
+
+	duo := buildAdminClient(ts.URL, nil)
+
+	users := []string{}
+	err := duo.EachUser(func(user User) error {
+		users = append(users, user.UserID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error from EachUser call %v", err)
+	}
+	if len(requests) != 2 {
+		t.Errorf("Expected two requests, found %d", len(requests))
+	}
+	if len(users) != 2 {
+		t.Errorf("Expected two users, found %v", users)
+	}
+	if offset := requests[1].URL.Query().Get("offset"); offset != "1" {
+		t.Errorf("Expected the second page at offset 1, found %q", offset)
+	}
+
+	stop := errors.New("stop")
+	requests = nil
+	err = duo.EachUser(func(user User) error {
+		return stop
+	})
+	if err != stop || len(requests) != 1 {
+		t.Errorf("Expected fn's error after one request, found %v after %d", err, len(requests))
+	}
+}
+
+func TestEachUserFailBody(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, `{"stat": "FAIL", "code": 40002, "message": "Invalid request parameters"}`)
+		}),
+	)
+	defer ts.Close()
+
+	duo := buildAdminClient(ts.URL, nil)
+
+	err := duo.EachUser(func(user User) error {
+		t.Errorf("Unexpected user %v", user)
+		return nil
+	})
+	if !duoapi.IsInvalidParams(err) {
+		t.Errorf("Expected an invalid parameters error, found %v", err)
+	}
+}
+
+func TestGetUsersContextCanceled(t *testing.T) {
+	requests := []*http.Request{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, getUsersPage1Response)
+			requests = append(requests, r)
+			// Cancel after the first page has been served.
+			cancel()
+		}),
+	)
+	defer ts.Close()
+
+	duo := buildAdminClient(ts.URL, nil)
+
+	result, err := duo.GetUsersContext(ctx)
+	if err == nil {
+		t.Fatal("Expected an error after the context was canceled")
+	}
+	if result != nil {
+		t.Errorf("Expected nil result, found %v", result)
+	}
+	if len(requests) != 1 {
+		t.Errorf("Expected one request, found %d", len(requests))
+	}
+}
+
+func TestGetUserNotFound(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, `{"stat": "FAIL", "code": 40401, "message": "Resource not found"}`)
+		}),
+	)
+	defer ts.Close()
+
+	duo := buildAdminClient(ts.URL, nil)
+
+	result, err := duo.GetUser("DU3RP9I2WOC59VZX672N")
+	if result != nil {
+		t.Errorf("Expected nil result, found %v", result)
+	}
+	if !duoapi.IsNotFound(err) {
+		t.Fatalf("Expected a not found error, found %v", err)
+	}
+	var duoErr *duoapi.Error
+	if !errors.As(err, &duoErr) {
+		t.Fatalf("Expected a *duoapi.Error, found %T", err)
+	}
+	if duoErr.Code != 40401 || duoErr.Message != "Resource not found" {
+		t.Errorf("Unexpected error contents: %v", duoErr)
+	}
+	if duoErr.Path != "/admin/v1/users/DU3RP9I2WOC59VZX672N" {
+		t.Errorf("Unexpected error path: %s", duoErr.Path)
+	}
+}
+
+func TestGetUserBaseURL(t *testing.T) {
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/stand-in/admin/v1/users/DU3RP9I2WOC59VZX672N" {
+				t.Errorf("Unexpected path %s", r.URL.Path)
+			}
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, `{"stat": "FAIL", "code": 40401, "message": "Resource not found"}`)
+		}),
+	)
+	defer ts.Close()
+
+	base, err := url.Parse(ts.URL + "/stand-in")
+	if err != nil {
+		t.Fatal(err)
+	}
+	duo := New(*duoapi.NewDuoApi("eyekey", "esskey", "api-test.duosecurity.com", "GoTestClient",
+		duoapi.SetBaseURL(base)))
+
+	_, err = duo.GetUser("DU3RP9I2WOC59VZX672N")
+	var duoErr *duoapi.Error
+	if !errors.As(err, &duoErr) {
+		t.Fatalf("Expected a *duoapi.Error, found %v", err)
+	}
+	if duoErr.Path != "/admin/v1/users/DU3RP9I2WOC59VZX672N" {
+		t.Errorf("Expected the error path without the base URL prefix, found %s", duoErr.Path)
+	}
+}
+
 const getEmptyPageArgsResponse = `{
 	"stat": "OK",
 	"metadata": {
@@ -1,7 +1,7 @@
 package admin
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -57,6 +57,65 @@ type AuthLogList struct {
 // Calls GET /admin/v2/logs/authentication
 // See https://duo.com/docs/adminapi#authentication-logs
 func (c *Client) GetAuthLogs(mintime time.Time, window time.Duration, options ...func(*url.Values)) (*AuthLogResult, error) {
+	return c.GetAuthLogsContext(context.Background(), mintime, window, options...)
+}
+
+// GetAuthLogsContext is like GetAuthLogs, but takes a context that bounds the request.
+func (c *Client) GetAuthLogsContext(ctx context.Context, mintime time.Time, window time.Duration, options ...func(*url.Values)) (*AuthLogResult, error) {
+	params := authLogParams(mintime, window, options...)
+
+	// Retrieve page of authentication logs and unmarshal received JSON into expected structure
+	result := &AuthLogResult{}
+	err := c.SignedCallInto(
+		ctx,
+		http.MethodGet,
+		"/admin/v2/logs/authentication",
+		params,
+		result,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// EachAuthLog calls fn with each authentication log in the time range
+// starting at mintime and ending at mintime + window, following
+// next_offset from page to page and decoding each page as it is read, so
+// that a long range is never held in memory at once.  It stops at the
+// first error, from a call or from fn, and returns it.
+// Calls GET /admin/v2/logs/authentication
+// See https://duo.com/docs/adminapi#authentication-logs
+func (c *Client) EachAuthLog(mintime time.Time, window time.Duration, fn func(AuthLog) error, options ...func(*url.Values)) error {
+	return c.EachAuthLogContext(context.Background(), mintime, window, fn, options...)
+}
+
+// EachAuthLogContext is like EachAuthLog, but takes a context that bounds the requests.
+func (c *Client) EachAuthLogContext(ctx context.Context, mintime time.Time, window time.Duration, fn func(AuthLog) error, options ...func(*url.Values)) error {
+	params := authLogParams(mintime, window, options...)
+	for {
+		result := &AuthLogResult{}
+		err := c.SignedCallInto(ctx, http.MethodGet, "/admin/v2/logs/authentication", params, result, duoapi.StreamResponse)
+		if err != nil {
+			return err
+		}
+		for _, log := range result.Response.Logs {
+			if err := fn(log); err != nil {
+				return err
+			}
+		}
+		next := result.Response.Metadata.GetNextOffset()
+		if next == nil {
+			return nil
+		}
+		next(&params)
+	}
+}
+
+// authLogParams returns the parameters of a request for authentication
+// logs from mintime to mintime + window.
+func authLogParams(mintime time.Time, window time.Duration, options ...func(*url.Values)) url.Values {
 	// Format mintime & maxtime parameters
 	minMs := mintime.UnixNano() / int64(time.Millisecond)
 	maxMs := mintime.Add(window).UnixNano() / int64(time.Millisecond)
@@ -73,27 +132,7 @@ func (c *Client) GetAuthLogs(mintime time.Time, window time.Duration, options ..
 	for _, opt := range options {
 		opt(&params)
 	}
-
-	// Retrieve page of authentication logs
-	resp, body, err := c.SignedCall(
-		http.MethodGet,
-		"/admin/v2/logs/authentication",
-		params,
-	)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("invalid HTTP response code from Duo API: [%d] %s", resp.StatusCode, resp.Status)
-	}
-
-	// Unmarshal received JSON into expected structure
-	result := &AuthLogResult{}
-	if err = json.Unmarshal(body, result); err != nil {
-		return nil, err
-	}
-
-	return result, nil
+	return params
 }
 
 /*
@@ -237,6 +276,11 @@ func (logs AdminLogList) GetNextOffset(maxtime time.Time) func(params *url.Value
 // Calls GET /admin/v1/logs/administrator
 // See https://duo.com/docs/adminapi#administrator-logs
 func (c *Client) GetAdminLogs(mintime time.Time, options ...func(*url.Values)) (*AdminLogResult, error) {
+	return c.GetAdminLogsContext(context.Background(), mintime, options...)
+}
+
+// GetAdminLogsContext is like GetAdminLogs, but takes a context that bounds the request.
+func (c *Client) GetAdminLogsContext(ctx context.Context, mintime time.Time, options ...func(*url.Values)) (*AdminLogResult, error) {
 	// Format mintime parameter
 	min := mintime.UnixNano() / int64(time.Second)
 	mintimeStr := strconv.FormatInt(min, 10)
@@ -251,24 +295,18 @@ func (c *Client) GetAdminLogs(mintime time.Time, options ...func(*url.Values)) (
 		opt(&params)
 	}
 
-	// Retrieve page of admin logs
-	resp, body, err := c.SignedCall(
+	// Retrieve page of admin logs and unmarshal received JSON into expected structure
+	result := &AdminLogResult{}
+	err := c.SignedCallInto(
+		ctx,
 		http.MethodGet,
 		"/admin/v1/logs/administrator",
 		params,
+		result,
 	)
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("invalid HTTP response code from Duo API: [%d] %s", resp.StatusCode, resp.Status)
-	}
-
-	// Unmarshal received JSON into expected structure
-	result := &AdminLogResult{}
-	if err = json.Unmarshal(body, result); err != nil {
-		return nil, err
-	}
 
 	return result, nil
 }
@@ -317,6 +355,11 @@ func (logs TelephonyLogList) GetNextOffset(maxtime time.Time) func(params *url.V
 // Calls GET /admin/v1/logs/telephony
 // See https://duo.com/docs/adminapi#telephony-logs
 func (c *Client) GetTelephonyLogs(mintime time.Time, options ...func(*url.Values)) (*TelephonyLogResult, error) {
+	return c.GetTelephonyLogsContext(context.Background(), mintime, options...)
+}
+
+// GetTelephonyLogsContext is like GetTelephonyLogs, but takes a context that bounds the request.
+func (c *Client) GetTelephonyLogsContext(ctx context.Context, mintime time.Time, options ...func(*url.Values)) (*TelephonyLogResult, error) {
 	// Format mintime parameter
 	min := mintime.UnixNano() / int64(time.Second)
 	mintimeStr := strconv.FormatInt(min, 10)
@@ -331,24 +374,18 @@ func (c *Client) GetTelephonyLogs(mintime time.Time, options ...func(*url.Values
 		opt(&params)
 	}
 
-	// Retrieve page of telephony logs
-	resp, body, err := c.SignedCall(
+	// Retrieve page of telephony logs and unmarshal received JSON into expected structure
+	result := &TelephonyLogResult{}
+	err := c.SignedCallInto(
+		ctx,
 		http.MethodGet,
 		"/admin/v1/logs/telephony",
 		params,
+		result,
 	)
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("invalid HTTP response code from Duo API: [%d] %s", resp.StatusCode, resp.Status)
-	}
-
-	// Unmarshal received JSON into expected structure
-	result := &TelephonyLogResult{}
-	if err = json.Unmarshal(body, result); err != nil {
-		return nil, err
-	}
 
 	return result, nil
 }
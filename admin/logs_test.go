@@ -7,6 +7,8 @@ import (
 	"net/url"
 	"testing"
 	"time"
+
+	duoapi "github.com/duosecurity/duo_api_golang"
 )
 
 /*
@@ -153,6 +155,70 @@ func TestGetAuthLogs(t *testing.T) {
 	}
 }
 
+// TestGetAuthLogsError ensures a failed request is reported as a *duoapi.Error.
+func TestEachAuthLog(t *testing.T) {
+	requests := []*http.Request{}
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(requests) == 0 {
+				fmt.Fprintln(w, getAuthLogsResponse)
+			} else {
+				fmt.Fprintln(w, `{"stat": "OK", "response": {"authlogs": [], "metadata": {"next_offset": null, "total_objects": 1}}}`)
+			}
+			requests = append(requests, r)
+		}),
+	)
+	defer ts.Close()
+
+	duo := buildAdminClient(ts.URL, nil)
+
+	txids := []interface{}{}
+	err := duo.EachAuthLog(time.Unix(1532951960, 0), 5*time.Second, func(log AuthLog) error {
+		txids = append(txids, log["txid"])
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error from EachAuthLog call: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Errorf("Expected two requests, but got %d", len(requests))
+	}
+	if len(txids) != 1 || txids[0] != "340a23e3-23f3-23c1-87dc-1491a23dfdbb" {
+		t.Errorf("Unexpected logs %v", txids)
+	}
+	query := requests[1].URL.Query()
+	if next := query.Get("next_offset"); next != "1532951895000,af0ba235-0b33-23c8-bc23-a31aa0231de8" {
+		t.Errorf("Expected the second page after the first's next_offset, but got %q", next)
+	}
+	if mintime := query.Get("mintime"); mintime != "1532951960000" {
+		t.Errorf("Expected every page to keep mintime, but got %q", mintime)
+	}
+}
+
+func TestGetAuthLogsError(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, `{"stat": "FAIL", "code": 40003, "message": "Invalid request parameters", "message_detail": "maxtime"}`)
+		}),
+	)
+	defer ts.Close()
+
+	duo := buildAdminClient(ts.URL, nil)
+
+	result, err := duo.GetAuthLogs(time.Unix(1532951960, 0), 5*time.Second)
+	if result != nil {
+		t.Errorf("Expected nil result, got %v", result)
+	}
+	if !duoapi.IsInvalidParams(err) {
+		t.Fatalf("Expected an invalid parameters error, got %v", err)
+	}
+	expected := "duoapi: /admin/v2/logs/authentication failed with HTTP 400, code 40003: Invalid request parameters (maxtime)"
+	if err.Error() != expected {
+		t.Errorf("Expected error %q, got %q", expected, err.Error())
+	}
+}
+
 // getAdminLogsResponse is an example response from the Duo API documentation example: https://duo.com/docs/adminapi#administrator-logs
 const getAdminLogsResponse = `{
 	"stat": "OK",
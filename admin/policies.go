@@ -0,0 +1,164 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	duoapi "github.com/duosecurity/duo_api_golang"
+)
+
+// Policy models a Duo policy.  Sections maps each policy section, such as
+// "authentication_methods", to its settings, which vary by section.  Every
+// field is omitted from requests when empty, so that UpdatePolicy only
+// changes the fields which are set.
+type Policy struct {
+	PolicyKey        string                            `json:"policy_key,omitempty"`
+	PolicyName       string                            `json:"policy_name,omitempty"`
+	IsGlobalPolicy   bool                              `json:"is_global_policy,omitempty"`
+	Sections         map[string]map[string]interface{} `json:"sections,omitempty"`
+	SectionsToDelete []string                          `json:"sections_to_delete,omitempty"`
+}
+
+// Policy methods
+
+// GetPoliciesResult models responses containing a list of policies.
+type GetPoliciesResult struct {
+	duoapi.StatResult
+	ListResult
+	Response []Policy
+}
+
+func (result *GetPoliciesResult) getResponse() interface{} {
+	return result.Response
+}
+
+func (result *GetPoliciesResult) appendResponse(policies interface{}) {
+	asserted_policies := policies.([]Policy)
+	result.Response = append(result.Response, asserted_policies...)
+}
+
+// GetPolicyResult models responses containing a single policy.
+type GetPolicyResult struct {
+	duoapi.StatResult
+	Response Policy
+}
+
+// GetPolicies calls GET /admin/v2/policies
+// See https://duo.com/docs/adminapi#retrieve-policies
+func (c *Client) GetPolicies(options ...func(*url.Values)) (*GetPoliciesResult, error) {
+	return c.GetPoliciesContext(context.Background(), options...)
+}
+
+// GetPoliciesContext is like GetPolicies, but takes a context that bounds the request.
+func (c *Client) GetPoliciesContext(ctx context.Context, options ...func(*url.Values)) (*GetPoliciesResult, error) {
+	params := url.Values{}
+	for _, o := range options {
+		o(&params)
+	}
+
+	cb := func(params url.Values) (responsePage, error) {
+		return c.retrievePolicies(ctx, params)
+	}
+	response, err := c.retrieveItems(params, cb)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.(*GetPoliciesResult), nil
+}
+
+func (c *Client) retrievePolicies(ctx context.Context, params url.Values) (*GetPoliciesResult, error) {
+	result := &GetPoliciesResult{}
+	err := c.SignedCallInto(ctx, http.MethodGet, "/admin/v2/policies", params, result, duoapi.UseTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetPolicy calls GET /admin/v2/policies/:policy_key
+// See https://duo.com/docs/adminapi#retrieve-policy-by-id
+func (c *Client) GetPolicy(policyKey string) (*GetPolicyResult, error) {
+	return c.GetPolicyContext(context.Background(), policyKey)
+}
+
+// GetPolicyContext is like GetPolicy, but takes a context that bounds the request.
+func (c *Client) GetPolicyContext(ctx context.Context, policyKey string) (*GetPolicyResult, error) {
+	path := fmt.Sprintf("/admin/v2/policies/%s", policyKey)
+
+	result := &GetPolicyResult{}
+	err := c.SignedCallInto(ctx, http.MethodGet, path, nil, result, duoapi.UseTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetGlobalPolicy calls GET /admin/v2/policies/global
+// See https://duo.com/docs/adminapi#retrieve-global-policy
+func (c *Client) GetGlobalPolicy() (*GetPolicyResult, error) {
+	return c.GetGlobalPolicyContext(context.Background())
+}
+
+// GetGlobalPolicyContext is like GetGlobalPolicy, but takes a context that bounds the request.
+func (c *Client) GetGlobalPolicyContext(ctx context.Context) (*GetPolicyResult, error) {
+	return c.GetPolicyContext(ctx, "global")
+}
+
+// CreatePolicy calls POST /admin/v2/policies with policy as its JSON body.
+// See https://duo.com/docs/adminapi#create-policy
+func (c *Client) CreatePolicy(policy Policy) (*GetPolicyResult, error) {
+	return c.CreatePolicyContext(context.Background(), policy)
+}
+
+// CreatePolicyContext is like CreatePolicy, but takes a context that bounds the request.
+func (c *Client) CreatePolicyContext(ctx context.Context, policy Policy) (*GetPolicyResult, error) {
+	path := "/admin/v2/policies"
+
+	result := &GetPolicyResult{}
+	err := c.JSONSignedCallInto(ctx, http.MethodPost, path, nil, policy, result, duoapi.UseTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdatePolicy calls PUT /admin/v2/policies/:policy_key with policy as its
+// JSON body.  Sections not named in policy are left unchanged, unless listed
+// in SectionsToDelete.
+// See https://duo.com/docs/adminapi#update-policy
+func (c *Client) UpdatePolicy(policyKey string, policy Policy) (*GetPolicyResult, error) {
+	return c.UpdatePolicyContext(context.Background(), policyKey, policy)
+}
+
+// UpdatePolicyContext is like UpdatePolicy, but takes a context that bounds the request.
+func (c *Client) UpdatePolicyContext(ctx context.Context, policyKey string, policy Policy) (*GetPolicyResult, error) {
+	path := fmt.Sprintf("/admin/v2/policies/%s", policyKey)
+
+	result := &GetPolicyResult{}
+	err := c.JSONSignedCallInto(ctx, http.MethodPut, path, nil, policy, result, duoapi.UseTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeletePolicy calls DELETE /admin/v2/policies/:policy_key
+// See https://duo.com/docs/adminapi#delete-policy
+func (c *Client) DeletePolicy(policyKey string) (*duoapi.StatResult, error) {
+	return c.DeletePolicyContext(context.Background(), policyKey)
+}
+
+// DeletePolicyContext is like DeletePolicy, but takes a context that bounds the request.
+func (c *Client) DeletePolicyContext(ctx context.Context, policyKey string) (*duoapi.StatResult, error) {
+	path := fmt.Sprintf("/admin/v2/policies/%s", policyKey)
+
+	result := &duoapi.StatResult{}
+	err := c.SignedCallInto(ctx, http.MethodDelete, path, nil, result, duoapi.UseTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
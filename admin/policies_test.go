@@ -0,0 +1,186 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const getPoliciesResponse = `{
+	"stat": "OK",
+	"metadata": {
+		"total_objects": 2
+	},
+	"response": [{
+		"is_global_policy": true,
+		"policy_key": "POSTGI7VKT9A3DVMS5QU",
+		"policy_name": "Global Policy",
+		"sections": {
+			"authentication_methods": {
+				"allowed_auth_list": ["duo-push", "webauthn-roaming"],
+				"blocked_auth_list": ["sms"]
+			}
+		}
+	}, {
+		"is_global_policy": false,
+		"policy_key": "POBVXSG7WNXHKAW8U4T3",
+		"policy_name": "Contractors",
+		"sections": {}
+	}]
+}`
+
+func TestGetPolicies(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, getPoliciesResponse)
+		}),
+	)
+	defer ts.Close()
+
+	duo := buildAdminClient(ts.URL, nil)
+
+	result, err := duo.GetPolicies()
+	if err != nil {
+		t.Fatalf("Unexpected error from GetPolicies call %v", err.Error())
+	}
+	if result.Stat != "OK" {
+		t.Errorf("Expected OK, but got %s", result.Stat)
+	}
+	if len(result.Response) != 2 {
+		t.Fatalf("Expected 2 policies, but got %d", len(result.Response))
+	}
+	global := result.Response[0]
+	if !global.IsGlobalPolicy || global.PolicyKey != "POSTGI7VKT9A3DVMS5QU" {
+		t.Errorf("Unexpected global policy %+v", global)
+	}
+	allowed := global.Sections["authentication_methods"]["allowed_auth_list"].([]interface{})
+	if len(allowed) != 2 || allowed[0] != "duo-push" {
+		t.Errorf("Unexpected allowed_auth_list %v", allowed)
+	}
+}
+
+const createPolicyResponse = `{
+	"stat": "OK",
+	"response": {
+		"is_global_policy": false,
+		"policy_key": "POBVXSG7WNXHKAW8U4T3",
+		"policy_name": "Contractors",
+		"sections": {
+			"authentication_methods": {
+				"blocked_auth_list": ["sms", "voice"]
+			}
+		}
+	}
+}`
+
+func TestCreatePolicy(t *testing.T) {
+	var sent Policy
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				t.Errorf("Expected POST, but got %s", r.Method)
+			}
+			if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Expected a JSON body, but got Content-Type %q", ct)
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			if err := json.Unmarshal(body, &sent); err != nil {
+				t.Errorf("Failed to decode request body %q: %v", body, err)
+			}
+			fmt.Fprintln(w, createPolicyResponse)
+		}),
+	)
+	defer ts.Close()
+
+	duo := buildAdminClient(ts.URL, nil)
+
+	policy := Policy{
+		PolicyName: "Contractors",
+		Sections: map[string]map[string]interface{}{
+			"authentication_methods": {
+				"blocked_auth_list": []string{"sms", "voice"},
+			},
+		},
+	}
+	result, err := duo.CreatePolicy(policy)
+	if err != nil {
+		t.Fatalf("Unexpected error from CreatePolicy call %v", err.Error())
+	}
+	if result.Response.PolicyKey != "POBVXSG7WNXHKAW8U4T3" {
+		t.Errorf("Expected PolicyKey to be POBVXSG7WNXHKAW8U4T3, but got %s", result.Response.PolicyKey)
+	}
+	if sent.PolicyName != "Contractors" {
+		t.Errorf("Expected policy_name Contractors to be sent, but got %q", sent.PolicyName)
+	}
+	blocked := sent.Sections["authentication_methods"]["blocked_auth_list"].([]interface{})
+	if len(blocked) != 2 || blocked[1] != "voice" {
+		t.Errorf("Nested section was not sent intact: %v", sent.Sections)
+	}
+}
+
+func TestUpdatePolicy(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPut {
+				t.Errorf("Expected PUT, but got %s", r.Method)
+			}
+			if r.URL.Path != "/admin/v2/policies/POBVXSG7WNXHKAW8U4T3" {
+				t.Errorf("Unexpected path %s", r.URL.Path)
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			if string(body) != `{"policy_name":"Contractors","sections_to_delete":["browsers"]}` {
+				t.Errorf("Unexpected request body %s", body)
+			}
+			fmt.Fprintln(w, createPolicyResponse)
+		}),
+	)
+	defer ts.Close()
+
+	duo := buildAdminClient(ts.URL, nil)
+
+	_, err := duo.UpdatePolicy("POBVXSG7WNXHKAW8U4T3", Policy{
+		PolicyName:       "Contractors",
+		SectionsToDelete: []string{"browsers"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error from UpdatePolicy call %v", err.Error())
+	}
+}
+
+func TestUpdatePolicyPartial(t *testing.T) {
+	bodies := []string{}
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			fmt.Fprintln(w, createPolicyResponse)
+		}),
+	)
+	defer ts.Close()
+
+	duo := buildAdminClient(ts.URL, nil)
+
+	updates := []Policy{
+		{SectionsToDelete: []string{"browsers"}},
+		{Sections: map[string]map[string]interface{}{
+			"new_user": {"new_user_behavior": "deny"},
+		}},
+	}
+	expected := []string{
+		`{"sections_to_delete":["browsers"]}`,
+		`{"sections":{"new_user":{"new_user_behavior":"deny"}}}`,
+	}
+	for _, update := range updates {
+		if _, err := duo.UpdatePolicy("POBVXSG7WNXHKAW8U4T3", update); err != nil {
+			t.Fatalf("Unexpected error from UpdatePolicy call %v", err.Error())
+		}
+	}
+	for i, body := range bodies {
+		if body != expected[i] {
+			t.Errorf("Expected request body %s, but got %s", expected[i], body)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"fmt"
+	"strings"
+
+	duoapi "github.com/duosecurity/duo_api_golang"
+)
+
+// The String and GoString methods below format a copy of their value whose
+// secrets are masked.  The copy has an unexported type with no methods, so
+// that formatting it does not recurse.
+
+type integration Integration
+
+func (i Integration) redacted() integration {
+	r := integration(i)
+	if r.SecretKey != "" {
+		r.SecretKey = duoapi.Redacted
+	}
+	return r
+}
+
+// String describes the integration without its secret key.
+func (i Integration) String() string {
+	return fmt.Sprintf("%+v", i.redacted())
+}
+
+// GoString is like String, for the %#v verb.
+func (i Integration) GoString() string {
+	return strings.Replace(fmt.Sprintf("%#v", i.redacted()), "admin.integration", "admin.Integration", 1)
+}
+
+type stringArrayResult StringArrayResult
+
+// redacted masks every string in the result, since GetUserBypassCodes
+// returns bypass codes in a StringArrayResult.
+func (result StringArrayResult) redacted() stringArrayResult {
+	r := stringArrayResult(result)
+	r.Response = make([]string, len(result.Response))
+	for i := range r.Response {
+		r.Response[i] = duoapi.Redacted
+	}
+	return r
+}
+
+// String describes the result without its strings.
+func (result StringArrayResult) String() string {
+	return fmt.Sprintf("%+v", result.redacted())
+}
+
+// GoString is like String, for the %#v verb.
+func (result StringArrayResult) GoString() string {
+	return strings.Replace(fmt.Sprintf("%#v", result.redacted()), "admin.stringArrayResult", "admin.StringArrayResult", 1)
+}
@@ -0,0 +1,18 @@
+//go:build go1.21
+// +build go1.21
+
+package admin
+
+import "log/slog"
+
+// LogValue implements slog.LogValuer, logging the integration without its
+// secret key.
+func (i Integration) LogValue() slog.Value {
+	return slog.AnyValue(i.redacted())
+}
+
+// LogValue implements slog.LogValuer, logging the result without its
+// strings.
+func (result StringArrayResult) LogValue() slog.Value {
+	return slog.AnyValue(result.redacted())
+}
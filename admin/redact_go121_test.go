@@ -0,0 +1,29 @@
+//go:build go1.21
+// +build go1.21
+
+package admin
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	duoapi "github.com/duosecurity/duo_api_golang"
+)
+
+func TestLogValueRedactsSecrets(t *testing.T) {
+	integration := Integration{Name: "Web SDK", SecretKey: testSecretKey}
+	bypassCodes := StringArrayResult{Response: []string{"407176182"}}
+
+	var out bytes.Buffer
+	for _, handler := range []slog.Handler{slog.NewTextHandler(&out, nil), slog.NewJSONHandler(&out, nil)} {
+		slog.New(handler).Info("objects", "integration", integration, "codes", bypassCodes)
+	}
+	if strings.Contains(out.String(), testSecretKey) || strings.Contains(out.String(), "407176182") {
+		t.Errorf("Secret in log output:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Web SDK") || !strings.Contains(out.String(), duoapi.Redacted) {
+		t.Errorf("Expected the redacted integration in log output:\n%s", out.String())
+	}
+}
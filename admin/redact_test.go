@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	duoapi "github.com/duosecurity/duo_api_golang"
+)
+
+const testSecretKey = "integration-secret-0123456789abcdefghijk"
+
+func formatted(value interface{}) []string {
+	return []string{
+		fmt.Sprint(value),
+		fmt.Sprintf("%v", value),
+		fmt.Sprintf("%+v", value),
+		fmt.Sprintf("%#v", value),
+		fmt.Sprintf("%s", value),
+	}
+}
+
+func TestFormatRedactsSecrets(t *testing.T) {
+	client := New(*duoapi.NewDuoApi("DIWJ8X6AEYOR5OMC6TQ1", testSecretKey, "api-test.duosecurity.com", "go-client"))
+	integration := Integration{Name: "Web SDK", IntegrationKey: "DIABC", SecretKey: testSecretKey}
+	bypassCodes := StringArrayResult{
+		StatResult: duoapi.StatResult{Stat: "OK"},
+		Response:   []string{"407176182", "016931781"},
+	}
+
+	values := map[string]interface{}{
+		"client":             client,
+		"integration":        integration,
+		"integration result": &GetIntegrationResult{Response: integration},
+		"integrations":       []Integration{integration},
+		"bypass codes":       bypassCodes,
+		"bypass codes ptr":   &bypassCodes,
+	}
+	for name, value := range values {
+		for _, out := range formatted(value) {
+			for _, secret := range []string{testSecretKey, "407176182", "016931781"} {
+				if strings.Contains(out, secret) {
+					t.Errorf("%s: %q in formatted output %q", name, secret, out)
+				}
+			}
+		}
+	}
+
+	if out := fmt.Sprintf("%#v", integration); !strings.HasPrefix(out, "admin.Integration{") || !strings.Contains(out, `Name:"Web SDK"`) {
+		t.Errorf("Unexpected %%#v output %q", out)
+	}
+	if out := bypassCodes.String(); strings.Count(out, duoapi.Redacted) != 2 {
+		t.Errorf("Expected both codes to be redacted, but got %q", out)
+	}
+	if integration.SecretKey != testSecretKey {
+		t.Error("Formatting changed the integration")
+	}
+}
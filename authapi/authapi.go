@@ -1,7 +1,7 @@
 package authapi
 
 import (
-	"encoding/json"
+	"context"
 	"net/url"
 	"strconv"
 
@@ -13,12 +13,21 @@ type AuthApi struct {
 }
 
 // Build a new Duo Auth API object.
-// api is a duoapi.DuoApi object used to make the Duo Rest API calls.
+// api is a duoapi.DuoApi object used to make the Duo Rest API calls.  Copies
+// of a DuoApi share its state, so clients built from one DuoApi share it too.
 // Example: authapi.NewAuthApi(*duoapi.NewDuoApi(ikey,skey,host,userAgent,duoapi.SetTimeout(10*time.Second)))
 func NewAuthApi(api duoapi.DuoApi) *AuthApi {
 	return &AuthApi{api}
 }
 
+// FromDuoApi returns an AuthApi which makes its calls with a copy of api,
+// sharing its state.
+//
+// Example: authapi.FromDuoApi(duoapi.NewDuoApi(ikey,skey,host,userAgent,duoapi.SetTimeout(10*time.Second)))
+func FromDuoApi(api *duoapi.DuoApi) *AuthApi {
+	return &AuthApi{*api}
+}
+
 // Leaving for backwards compatibility.
 // The struct in use has been moved to the duoapi package, to be shared between the admin and authapi packages.
 type StatResult struct {
@@ -40,12 +49,13 @@ type PingResult struct {
 // This is an unsigned Duo Rest API call which returns the Duo system's time.
 // Use this method to determine whether your system time is in sync with Duo's.
 func (api *AuthApi) Ping() (*PingResult, error) {
-	_, body, err := api.Call("GET", "/auth/v2/ping", nil, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
+	return api.PingContext(context.Background())
+}
+
+// PingContext is like Ping, but takes a context that bounds the request.
+func (api *AuthApi) PingContext(ctx context.Context) (*PingResult, error) {
 	ret := &PingResult{}
-	if err = json.Unmarshal(body, ret); err != nil {
+	if err := api.CallInto(ctx, "GET", "/auth/v2/ping", nil, ret, duoapi.UseTimeout); err != nil {
 		return nil, err
 	}
 	return ret, nil
@@ -64,12 +74,13 @@ type CheckResult struct {
 // Use this method to determine whether your ikey, skey and host are correct,
 // and whether your system time is in sync with Duo's.
 func (api *AuthApi) Check() (*CheckResult, error) {
-	_, body, err := api.SignedCall("GET", "/auth/v2/check", nil, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
+	return api.CheckContext(context.Background())
+}
+
+// CheckContext is like Check, but takes a context that bounds the request.
+func (api *AuthApi) CheckContext(ctx context.Context) (*CheckResult, error) {
 	ret := &CheckResult{}
-	if err = json.Unmarshal(body, ret); err != nil {
+	if err := api.SignedCallInto(ctx, "GET", "/auth/v2/check", nil, ret, duoapi.UseTimeout); err != nil {
 		return nil, err
 	}
 	return ret, nil
@@ -82,22 +93,24 @@ type LogoResult struct {
 }
 
 // Duo's Logo method. https://www.duosecurity.com/docs/authapi#/logo
-// If the API call is successful, the configured logo png is returned.  Othwerwise,
-// error information is returned in the LogoResult return value.
+// If the API call is successful, the configured logo png is returned.  Otherwise,
+// a *duoapi.Error describing the failure is returned.
 func (api *AuthApi) Logo() (*LogoResult, error) {
-	resp, body, err := api.SignedCall("GET", "/auth/v2/logo", nil, duoapi.UseTimeout)
+	return api.LogoContext(context.Background())
+}
+
+// LogoContext is like Logo, but takes a context that bounds the request.
+func (api *AuthApi) LogoContext(ctx context.Context) (*LogoResult, error) {
+	var meta duoapi.ResponseMeta
+	resp, body, err := api.SignedCallContext(ctx, "GET", "/auth/v2/logo", nil, duoapi.UseTimeout, duoapi.ReportResponseMeta(&meta))
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode == 200 {
-		ret := &LogoResult{StatResult: duoapi.StatResult{Stat: "OK"},
-			png: &body}
-		return ret, nil
-	}
-	ret := &LogoResult{}
-	if err = json.Unmarshal(body, ret); err != nil {
+	if err = duoapi.CheckResponse(resp, body); err != nil {
 		return nil, err
 	}
+	ret := &LogoResult{StatResult: duoapi.StatResult{Stat: "OK", Meta: meta},
+		png: &body}
 	return ret, nil
 }
 
@@ -132,17 +145,18 @@ type EnrollResult struct {
 // Use EnrollValidSeconds() to change the default validation time limit that the
 // user has to complete enrollment.
 func (api *AuthApi) Enroll(options ...func(*url.Values)) (*EnrollResult, error) {
+	return api.EnrollContext(context.Background(), options...)
+}
+
+// EnrollContext is like Enroll, but takes a context that bounds the request.
+func (api *AuthApi) EnrollContext(ctx context.Context, options ...func(*url.Values)) (*EnrollResult, error) {
 	opts := url.Values{}
 	for _, o := range options {
 		o(&opts)
 	}
 
-	_, body, err := api.SignedCall("POST", "/auth/v2/enroll", opts, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
 	ret := &EnrollResult{}
-	if err = json.Unmarshal(body, ret); err != nil {
+	if err := api.SignedCallInto(ctx, "POST", "/auth/v2/enroll", opts, ret, duoapi.UseTimeout); err != nil {
 		return nil, err
 	}
 	return ret, nil
@@ -157,21 +171,19 @@ type EnrollStatusResult struct {
 // Duo's EnrollStatus method. https://www.duosecurity.com/docs/authapi#/enroll_status
 // Return the status of an outstanding Enrollment.
 func (api *AuthApi) EnrollStatus(userid string,
+	activationCode string) (*EnrollStatusResult, error) {
+	return api.EnrollStatusContext(context.Background(), userid, activationCode)
+}
+
+// EnrollStatusContext is like EnrollStatus, but takes a context that bounds the request.
+func (api *AuthApi) EnrollStatusContext(ctx context.Context, userid string,
 	activationCode string) (*EnrollStatusResult, error) {
 	queryArgs := url.Values{}
 	queryArgs.Set("user_id", userid)
 	queryArgs.Set("activation_code", activationCode)
 
-	_, body, err := api.SignedCall("POST",
-		"/auth/v2/enroll_status",
-		queryArgs,
-		duoapi.UseTimeout)
-
-	if err != nil {
-		return nil, err
-	}
 	ret := &EnrollStatusResult{}
-	if err = json.Unmarshal(body, ret); err != nil {
+	if err := api.SignedCallInto(ctx, "POST", "/auth/v2/enroll_status", queryArgs, ret, duoapi.UseTimeout); err != nil {
 		return nil, err
 	}
 	return ret, nil
@@ -227,16 +239,17 @@ func PreauthTrustedToken(trustedtoken string) func(*url.Values) {
 // of the client attempting authroization.
 // Use PreauthTrustedToken to specify the trusted_device_token parameter.
 func (api *AuthApi) Preauth(options ...func(*url.Values)) (*PreauthResult, error) {
+	return api.PreauthContext(context.Background(), options...)
+}
+
+// PreauthContext is like Preauth, but takes a context that bounds the request.
+func (api *AuthApi) PreauthContext(ctx context.Context, options ...func(*url.Values)) (*PreauthResult, error) {
 	opts := url.Values{}
 	for _, o := range options {
 		o(&opts)
 	}
-	_, body, err := api.SignedCall("POST", "/auth/v2/preauth", opts, duoapi.UseTimeout)
-	if err != nil {
-		return nil, err
-	}
 	ret := &PreauthResult{}
-	if err = json.Unmarshal(body, ret); err != nil {
+	if err := api.SignedCallInto(ctx, "POST", "/auth/v2/preauth", opts, ret, duoapi.UseTimeout); err != nil {
 		return nil, err
 	}
 	return ret, nil
@@ -329,6 +342,11 @@ type AuthResult struct {
 // When using factor 'sms' or 'phone', use AuthDevice to specify which device
 // should receive the SMS or phone call.
 func (api *AuthApi) Auth(factor string, options ...func(*url.Values)) (*AuthResult, error) {
+	return api.AuthContext(context.Background(), factor, options...)
+}
+
+// AuthContext is like Auth, but takes a context that bounds the request.
+func (api *AuthApi) AuthContext(ctx context.Context, factor string, options ...func(*url.Values)) (*AuthResult, error) {
 	params := url.Values{}
 	for _, o := range options {
 		o(&params)
@@ -340,12 +358,8 @@ func (api *AuthApi) Auth(factor string, options ...func(*url.Values)) (*AuthResu
 		apiOps = append(apiOps, duoapi.UseTimeout)
 	}
 
-	_, body, err := api.SignedCall("POST", "/auth/v2/auth", params, apiOps...)
-	if err != nil {
-		return nil, err
-	}
 	ret := &AuthResult{}
-	if err = json.Unmarshal(body, ret); err != nil {
+	if err := api.SignedCallInto(ctx, "POST", "/auth/v2/auth", params, ret, apiOps...); err != nil {
 		return nil, err
 	}
 	return ret, nil
@@ -367,14 +381,15 @@ type AuthStatusResult struct {
 // result of the authentication attempt.
 // txid is returned by the Auth call.
 func (api *AuthApi) AuthStatus(txid string) (*AuthStatusResult, error) {
+	return api.AuthStatusContext(context.Background(), txid)
+}
+
+// AuthStatusContext is like AuthStatus, but takes a context that bounds the request.
+func (api *AuthApi) AuthStatusContext(ctx context.Context, txid string) (*AuthStatusResult, error) {
 	opts := url.Values{}
 	opts.Set("txid", txid)
-	_, body, err := api.SignedCall("GET", "/auth/v2/auth_status", opts)
-	if err != nil {
-		return nil, err
-	}
 	ret := &AuthStatusResult{}
-	if err = json.Unmarshal(body, ret); err != nil {
+	if err := api.SignedCallInto(ctx, "GET", "/auth/v2/auth_status", opts, ret); err != nil {
 		return nil, err
 	}
 	return ret, nil
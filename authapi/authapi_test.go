@@ -1,6 +1,8 @@
 package authapi
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -197,9 +199,12 @@ func TestLogo(t *testing.T) {
 
 	duo := buildAuthApi(ts.URL, nil)
 
-	_, err := duo.Logo()
+	result, err := duo.Logo()
 	if err != nil {
-		t.Error("Failed TestCheck: " + err.Error())
+		t.Fatal("Failed TestCheck: " + err.Error())
+	}
+	if result.Meta.StatusCode != 200 || result.Meta.Header.Get("Content-Type") != "image/png" {
+		t.Errorf("Unexpected response meta %+v", result.Meta)
 	}
 }
 
@@ -223,21 +228,59 @@ func TestLogoError(t *testing.T) {
 	duo := buildAuthApi(ts.URL, nil)
 
 	res, err := duo.Logo()
-	if err != nil {
-		t.Error("Failed TestCheck: " + err.Error())
+	if res != nil {
+		t.Error("Expected nil result for a failed Logo call")
+	}
+	var duoErr *duoapi.Error
+	if !errors.As(err, &duoErr) {
+		t.Fatalf("Expected a *duoapi.Error, but got %v", err)
 	}
-	if res.Stat != "FAIL" {
-		t.Error("Expected FAIL, but got " + res.Stat)
+	if duoErr.StatusCode != 400 {
+		t.Errorf("Unexpected status code: %d", duoErr.StatusCode)
 	}
-	if res.Code == nil || *res.Code != 40002 {
+	if duoErr.Code != 40002 {
 		t.Error("Unexpected response code.")
 	}
-	if res.Message == nil || *res.Message != "Logo not found" {
+	if duoErr.Message != "Logo not found" {
 		t.Error("Unexpected message.")
 	}
-	if res.Message_Detail == nil || *res.Message_Detail != "Why u no have logo?" {
+	if duoErr.MessageDetail != "Why u no have logo?" {
 		t.Error("Unexpected message detail.")
 	}
+	if duoErr.Path != "/auth/v2/logo" {
+		t.Error("Unexpected path: " + duoErr.Path)
+	}
+	if !duoapi.IsInvalidParams(err) {
+		t.Error("Expected IsInvalidParams to match a 400 response")
+	}
+}
+
+// Test that a FAIL stat is reported as an error even with a 200 status.
+func TestPreauthFailStat(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, `
+                {
+                    "stat": "FAIL",
+                    "code": 40103,
+                    "message": "Invalid signature in request credentials"
+                }`)
+			}))
+	defer ts.Close()
+
+	duo := buildAuthApi(ts.URL, nil)
+
+	res, err := duo.Preauth(PreauthUsername("user"))
+	if res != nil {
+		t.Error("Expected nil result for a failed Preauth call")
+	}
+	if !duoapi.IsUnauthorized(err) {
+		t.Errorf("Expected an unauthorized error, but got %v", err)
+	}
+	if !errors.Is(err, duoapi.ErrUnauthorized) {
+		t.Error("Expected errors.Is to match duoapi.ErrUnauthorized")
+	}
 }
 
 // Test a successful enroll request / response.
@@ -602,3 +645,58 @@ func TestAuthStatus(t *testing.T) {
 		t.Error("Unexpected response status msg: " + res.Response.Status_Msg)
 	}
 }
+
+// Test that a blocking Auth call is abandoned when its context is canceled.
+func TestAuthContextCanceled(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				<-release
+			}))
+	defer ts.Close()
+	defer close(release)
+
+	duo := buildAuthApi(ts.URL, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	res, err := duo.AuthContext(ctx, "push", AuthUsername("user"))
+	if err == nil {
+		t.Fatal("Expected an error from a canceled Auth call")
+	}
+	if res != nil {
+		t.Error("Expected nil result from a canceled Auth call")
+	}
+	if ctx.Err() != context.DeadlineExceeded {
+		t.Errorf("Expected context deadline to be exceeded, got %v", ctx.Err())
+	}
+	if duration := time.Since(start); duration.Seconds() > 2 {
+		t.Errorf("Canceled Auth call took %v seconds", duration.Seconds())
+	}
+}
+
+// AuthApi values, built any of the ways callers build them, share the state
+// of the DuoApi they were built from.
+func TestAuthApiSharesState(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"stat": "OK", "response": {"time": 1357020061}}`)
+	}))
+	defer ts.Close()
+
+	limiter := duoapi.NewRateLimiter(duoapi.RateLimitFailFast, duoapi.RateLimit{Prefix: "/auth/", Rate: 0.001, Burst: 2})
+	api := duoapi.NewDuoApi("eyekey", "esskey", strings.Split(ts.URL, "//")[1], "GoTestClient",
+		duoapi.SetInsecure(), duoapi.SetRateLimiter(limiter))
+	clients := []*AuthApi{FromDuoApi(api), NewAuthApi(*api), &AuthApi{DuoApi: *api}}
+
+	for i, client := range clients[:2] {
+		if _, err := client.Ping(); err != nil {
+			t.Fatalf("Call %d within the shared budget failed: %v", i+1, err)
+		}
+	}
+	if _, err := clients[2].Ping(); err != duoapi.ErrRateLimitExceeded {
+		t.Fatalf("Expected the budget to be shared, but got %v", err)
+	}
+}
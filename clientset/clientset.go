@@ -0,0 +1,71 @@
+// Package clientset builds Auth, Admin and Accounts API clients which share
+// one configuration, and one set of per-client state such as the clock skew
+// correction, logger and observer, while signing calls with their own
+// integration's keys.  Since Duo's rate limits apply to each integration,
+// clients with different integration keys get their own copies of any rate
+// limiter in the options, while clients with the same key share one.
+package clientset
+
+import (
+	duoapi "github.com/duosecurity/duo_api_golang"
+	"github.com/duosecurity/duo_api_golang/accounts"
+	"github.com/duosecurity/duo_api_golang/admin"
+	"github.com/duosecurity/duo_api_golang/authapi"
+)
+
+// Config configures a ClientSet.
+type Config struct {
+	// Host is your Duo API hostname.
+	Host string
+	// UserAgent is prefixed to the user agent the clients send.
+	UserAgent string
+	// Auth, Admin and Accounts hold the keys of an Auth API, an Admin API
+	// and an Accounts API application.  A client is built only for keys
+	// which are set.
+	Auth     duoapi.Credentials
+	Admin    duoapi.Credentials
+	Accounts duoapi.Credentials
+	// Options are passed to duoapi.NewDuoApi for the shared client.
+	Options []duoapi.ApiOption
+}
+
+// ClientSet holds the Auth, Admin and Accounts API clients built from one
+// Config.
+type ClientSet struct {
+	// Auth is nil unless Config.Auth was set.
+	Auth *authapi.AuthApi
+	// Admin is nil unless Config.Admin was set.
+	Admin *admin.Client
+	// Accounts is nil unless Config.Accounts was set.
+	Accounts *accounts.Client
+}
+
+// New returns the clients configured by config.
+//
+// Example: clientset.New(clientset.Config{Host: host, Auth: authCreds, Admin: adminCreds, Options: []duoapi.ApiOption{duoapi.SetTimeout(10*time.Second)}})
+func New(config Config) *ClientSet {
+	base := duoapi.NewDuoApi("", "", config.Host, config.UserAgent, config.Options...)
+	// byIKey holds the first client built for each integration key, whose
+	// rate limiter later clients with that key share.
+	byIKey := make(map[string]*duoapi.DuoApi)
+	withCredentials := func(creds duoapi.Credentials) *duoapi.DuoApi {
+		if api, ok := byIKey[creds.IKey]; ok {
+			return api.WithCredentials(creds.IKey, creds.SKey)
+		}
+		api := base.WithCredentials(creds.IKey, creds.SKey, duoapi.SeparateRateLimiter)
+		byIKey[creds.IKey] = api
+		return api
+	}
+
+	set := &ClientSet{}
+	if config.Auth.IKey != "" {
+		set.Auth = authapi.FromDuoApi(withCredentials(config.Auth))
+	}
+	if config.Admin.IKey != "" {
+		set.Admin = admin.FromDuoApi(withCredentials(config.Admin))
+	}
+	if config.Accounts.IKey != "" {
+		set.Accounts = accounts.FromDuoApi(withCredentials(config.Accounts))
+	}
+	return set
+}
@@ -0,0 +1,106 @@
+package clientset_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	duoapi "github.com/duosecurity/duo_api_golang"
+	"github.com/duosecurity/duo_api_golang/admin"
+	"github.com/duosecurity/duo_api_golang/clientset"
+	"github.com/duosecurity/duo_api_golang/duoapitest"
+)
+
+func TestClientSet(t *testing.T) {
+	authCreds := duoapitest.Credentials{IKey: "DIAUTHAUTHAUTHAUTHAU", SKey: "auth-secret"}
+	adminCreds := duoapitest.Credentials{IKey: "DIADMINADMINADMINADM", SKey: "admin-secret"}
+	authServer := duoapitest.NewAuthServer(authCreds)
+	defer authServer.Close()
+	adminAPI := duoapitest.NewAdminAPI(adminCreds)
+	adminAPI.AddUser(admin.User{Username: "jsmith"})
+
+	// Serve both APIs from one host, as Duo does.
+	mux := http.NewServeMux()
+	mux.Handle("/auth/", authServer.Config.Handler)
+	mux.Handle("/admin/", adminAPI)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	base, _ := url.Parse(ts.URL)
+
+	metrics := duoapi.NewMetrics()
+	set := clientset.New(clientset.Config{
+		Host:    duoapitest.DefaultHost,
+		Auth:    duoapi.Credentials{IKey: authCreds.IKey, SKey: authCreds.SKey},
+		Admin:   duoapi.Credentials{IKey: adminCreds.IKey, SKey: adminCreds.SKey},
+		Options: []duoapi.ApiOption{duoapi.SetBaseURL(base), duoapi.SetObserver(metrics)},
+	})
+
+	if _, err := set.Auth.Check(); err != nil {
+		t.Fatalf("Auth API call failed: %v", err)
+	}
+	users, err := set.Admin.GetUsers()
+	if err != nil {
+		t.Fatalf("Admin API call failed: %v", err)
+	}
+	if len(users.Response) != 1 || users.Response[0].Username != "jsmith" {
+		t.Errorf("Unexpected users %+v", users.Response)
+	}
+
+	w := httptest.NewRecorder()
+	metrics.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	for _, endpoint := range []string{"/auth/v2/check", "/admin/v1/users"} {
+		if !strings.Contains(w.Body.String(), `endpoint="`+endpoint+`"`) {
+			t.Errorf("Expected both clients to share the observer, but %s is missing from:\n%s", endpoint, w.Body.String())
+		}
+	}
+}
+
+func TestClientSetWithoutAuth(t *testing.T) {
+	set := clientset.New(clientset.Config{
+		Host:  duoapitest.DefaultHost,
+		Admin: duoapi.Credentials{IKey: "DIADMINADMINADMINADM", SKey: "admin-secret"},
+	})
+	if set.Auth != nil || set.Admin == nil || set.Accounts != nil {
+		t.Errorf("Expected only an Admin client, but got %+v", set)
+	}
+}
+
+func TestClientSetRateLimiters(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/admin/") {
+			w.Write([]byte(`{"stat": "OK", "response": []}`))
+			return
+		}
+		w.Write([]byte(`{"stat": "OK", "response": {}}`))
+	}))
+	defer ts.Close()
+	base, _ := url.Parse(ts.URL)
+
+	newSet := func(adminIKey string) *clientset.ClientSet {
+		limiter := duoapi.NewRateLimiter(duoapi.RateLimitFailFast, duoapi.RateLimit{Prefix: "/", Rate: 0.001, Burst: 1})
+		return clientset.New(clientset.Config{
+			Host:    duoapitest.DefaultHost,
+			Auth:    duoapi.Credentials{IKey: "DIAUTHAUTHAUTHAUTHAU", SKey: "auth-secret"},
+			Admin:   duoapi.Credentials{IKey: adminIKey, SKey: "admin-secret"},
+			Options: []duoapi.ApiOption{duoapi.SetBaseURL(base), duoapi.SetRateLimiter(limiter)},
+		})
+	}
+
+	set := newSet("DIADMINADMINADMINADM")
+	if _, err := set.Auth.Check(); err != nil {
+		t.Fatalf("Auth API call failed: %v", err)
+	}
+	if _, err := set.Admin.GetUsers(); err != nil {
+		t.Fatalf("Expected a separate budget for another integration, but got %v", err)
+	}
+
+	set = newSet("DIAUTHAUTHAUTHAUTHAU")
+	if _, err := set.Auth.Check(); err != nil {
+		t.Fatalf("Auth API call failed: %v", err)
+	}
+	if _, err := set.Admin.GetUsers(); err != duoapi.ErrRateLimitExceeded {
+		t.Fatalf("Expected clients of one integration to share a budget, but got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package duoapi
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// clockSkew tracks how far the Duo server's clock is ahead of ours, as a
+// running average of the offsets seen in responses' Date headers.
+type clockSkew struct {
+	mu       sync.Mutex
+	offset   time.Duration
+	measured bool
+}
+
+// observe records the offset between resp's Date header and the midpoint
+// of the round trip which began at sent and ended at received.
+func (s *clockSkew) observe(sent, received time.Time, resp *http.Response) {
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return
+	}
+	sample := date.Sub(sent.Add(received.Sub(sent) / 2))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.measured {
+		s.offset = sample
+		s.measured = true
+		return
+	}
+	// Date only has one second resolution, so smooth out the noise.
+	s.offset = (3*s.offset + sample) / 4
+}
+
+func (s *clockSkew) get() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.offset
+}
+
+// SetClockSkewCorrection is an optional parameter for NewDuoApi which
+// measures the skew between the local clock and Duo's from the Date header
+// of every response, and corrects the Date header of signed requests by
+// it.  This avoids signature failures when the local clock drifts.
+func SetClockSkewCorrection() func(*apiOptions) {
+	return func(opts *apiOptions) {
+		opts.correctSkew = true
+	}
+}
+
+// ClockSkew returns how far Duo's clock has been measured to be ahead of
+// the local clock; it is negative if Duo's clock is behind.  It is always
+// zero unless SetClockSkewCorrection was given.
+func (duoapi *DuoApi) ClockSkew() time.Duration {
+	if duoapi.skew == nil {
+		return 0
+	}
+	return duoapi.skew.get()
+}
+
+// now returns the time to sign a request with, corrected for clock skew.
+func (duoapi *DuoApi) now() time.Time {
+	return time.Now().Add(duoapi.ClockSkew())
+}
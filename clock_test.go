@@ -0,0 +1,87 @@
+package duoapi
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func dateResp(date time.Time) http.Response {
+	header := http.Header{}
+	header.Set("Date", date.UTC().Format(http.TimeFormat))
+	return http.Response{
+		StatusCode: 200,
+		Header:     header,
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte("hello world"))),
+	}
+}
+
+func TestClockSkewCorrection(t *testing.T) {
+	ahead := time.Now().Add(time.Hour)
+	duo, mockHttp, _ := getMockClients([]http.Response{dateResp(ahead), dateResp(ahead)})
+	duo.skew = &clockSkew{}
+
+	if _, _, err := duo.SignedCall("GET", "/auth/v2/check", url.Values{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	skew := duo.ClockSkew()
+	if skew < time.Hour-2*time.Second || skew > time.Hour+2*time.Second {
+		t.Fatalf("Expected about an hour of skew, but measured %v", skew)
+	}
+
+	if _, _, err := duo.SignedCall("GET", "/auth/v2/check", url.Values{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	req := mockHttp.actualRequests[1]
+	date, err := time.Parse(time.RFC1123Z, req.Header.Get("Date"))
+	if err != nil {
+		t.Fatalf("Unparseable Date header: %v", err)
+	}
+	if offset := date.Sub(time.Now()); offset < time.Hour-3*time.Second || offset > time.Hour+3*time.Second {
+		t.Fatalf("Date header was not corrected: off by %v", offset)
+	}
+	expected := sign("ikey-foo", "skey-bar", "GET", "host.baz", "/auth/v2/check",
+		req.Header.Get("Date"), url.Values{})
+	if req.Header.Get("Authorization") != expected {
+		t.Fatal("Signature does not cover the corrected Date header")
+	}
+}
+
+func TestClockSkewRunningAverage(t *testing.T) {
+	skew := &clockSkew{}
+	sent := time.Unix(1500000000, 0)
+	received := sent.Add(2 * time.Second)
+	resp := func(offset time.Duration) *http.Response {
+		r := dateResp(sent.Add(time.Second + offset))
+		return &r
+	}
+
+	skew.observe(sent, received, resp(40*time.Second))
+	if skew.get() != 40*time.Second {
+		t.Fatalf("Expected the first sample to be taken as is, but got %v", skew.get())
+	}
+	skew.observe(sent, received, resp(0))
+	if skew.get() != 30*time.Second {
+		t.Fatalf("Expected the offset to move toward the new sample, but got %v", skew.get())
+	}
+
+	noDate := &http.Response{Header: http.Header{}}
+	skew.observe(sent, received, noDate)
+	if skew.get() != 30*time.Second {
+		t.Fatalf("Response without a Date changed the offset to %v", skew.get())
+	}
+}
+
+func TestSetClockSkewCorrection(t *testing.T) {
+	duo := NewDuoApi("ABC", "123", "api-XXXXXXX.duosecurity.com", "go-client")
+	if duo.skew != nil || duo.ClockSkew() != 0 {
+		t.Fatal("Clock skew was measured without SetClockSkewCorrection")
+	}
+	duo = NewDuoApi("ABC", "123", "api-XXXXXXX.duosecurity.com", "go-client", SetClockSkewCorrection())
+	if duo.skew == nil {
+		t.Fatal("SetClockSkewCorrection failed to enable skew measurement")
+	}
+}
@@ -0,0 +1,170 @@
+package duoapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// Credentials are the integration key and secret key which sign requests.
+type Credentials struct {
+	IKey string `json:"ikey"`
+	SKey string `json:"skey"`
+}
+
+// CredentialsProvider supplies the credentials for a DuoApi.  It is
+// consulted every time a request is signed, so a provider may return new
+// credentials after a key is rotated.  It must be safe for concurrent use.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+type staticCredentials struct {
+	ikey string
+	skey *secret
+}
+
+// StaticCredentials returns a CredentialsProvider which always returns ikey
+// and skey.
+func StaticCredentials(ikey, skey string) CredentialsProvider {
+	return staticCredentials{ikey: ikey, skey: newSecret(skey)}
+}
+
+func (c staticCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	return Credentials{IKey: c.ikey, SKey: c.skey.get()}, nil
+}
+
+type envCredentials struct {
+	ikeyVar string
+	skeyVar string
+}
+
+// EnvCredentials returns a CredentialsProvider which reads the integration
+// and secret keys from the environment variables ikeyVar and skeyVar each
+// time they are needed.
+func EnvCredentials(ikeyVar, skeyVar string) CredentialsProvider {
+	return envCredentials{ikeyVar: ikeyVar, skeyVar: skeyVar}
+}
+
+func (c envCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	creds := Credentials{
+		IKey: os.Getenv(c.ikeyVar),
+		SKey: os.Getenv(c.skeyVar),
+	}
+	if creds.IKey == "" || creds.SKey == "" {
+		return Credentials{}, fmt.Errorf("duoapi: %s and %s must both be set", c.ikeyVar, c.skeyVar)
+	}
+	return creds, nil
+}
+
+// FileCredentialsProvider reads credentials from a JSON file of the form
+// {"ikey": "...", "skey": "..."}.  The file is read again whenever its
+// modification time or size changes, and on every call while it was last
+// modified too recently to tell whether it has changed since.
+type FileCredentialsProvider struct {
+	path string
+
+	mu      sync.Mutex
+	ikey    string
+	skey    *secret
+	modTime time.Time
+	size    int64
+	readAt  time.Time
+}
+
+// modTimeGranularity is the coarsest resolution of file modification times
+// on common filesystems.  A file rewritten within that long of an earlier
+// write may keep its modification time, and skeys keep their length, so
+// such a file can only be trusted unchanged once it is older than that.
+const modTimeGranularity = 2 * time.Second
+
+// FileCredentials returns a FileCredentialsProvider for the file at path.
+// The file is not read until credentials are first needed.
+func FileCredentials(path string) *FileCredentialsProvider {
+	return &FileCredentialsProvider{path: path}
+}
+
+// Credentials implements CredentialsProvider.
+func (p *FileCredentialsProvider) Credentials(ctx context.Context) (Credentials, error) {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ikey != "" && info.ModTime().Equal(p.modTime) && info.Size() == p.size &&
+		p.modTime.Before(p.readAt.Add(-modTimeGranularity)) {
+		return Credentials{IKey: p.ikey, SKey: p.skey.get()}, nil
+	}
+
+	readAt := time.Now()
+	data, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return Credentials{}, err
+	}
+	creds := Credentials{}
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return Credentials{}, fmt.Errorf("duoapi: reading credentials from %s: %v", p.path, err)
+	}
+	if creds.IKey == "" || creds.SKey == "" {
+		return Credentials{}, fmt.Errorf("duoapi: %s must set both ikey and skey", p.path)
+	}
+	p.ikey, p.skey = creds.IKey, newSecret(creds.SKey)
+	p.modTime = info.ModTime()
+	p.size = info.Size()
+	p.readAt = readAt
+	return creds, nil
+}
+
+// SetCredentialsProvider is an optional parameter for NewDuoApi which signs
+// requests with the credentials from provider, in place of the ikey and
+// skey given to NewDuoApi.
+//
+// Example: duoapi.NewDuoApi("", "", host, userAgent, duoapi.SetCredentialsProvider(duoapi.FileCredentials(path)))
+func SetCredentialsProvider(provider CredentialsProvider) func(*apiOptions) {
+	return func(opts *apiOptions) {
+		opts.credentials = provider
+	}
+}
+
+type credentialsOptions struct {
+	separateLimiter bool
+}
+
+// CredentialsOption is an optional parameter for WithCredentials.
+type CredentialsOption func(*credentialsOptions)
+
+// SeparateRateLimiter is passed to WithCredentials to give the new DuoApi a
+// rate limiter of its own, with the same limits as the original's but
+// fresh budgets, for credentials of a different integration.
+func SeparateRateLimiter(opts *credentialsOptions) {
+	opts.separateLimiter = true
+}
+
+// WithCredentials returns a DuoApi which signs calls with ikey and skey,
+// instead of duoapi's keys or credentials provider, but otherwise shares
+// duoapi's configuration and state: its HTTP clients, retry policy, rate
+// limiter, clock skew correction, logger and observer.  Duo's rate limits
+// apply to each integration, so pass SeparateRateLimiter if ikey is not
+// the integration whose budgets duoapi's limiter tracks.
+//
+// Example: adminApi := authApi.WithCredentials(adminIKey, adminSKey, duoapi.SeparateRateLimiter)
+func (duoapi *DuoApi) WithCredentials(ikey string, skey string, options ...CredentialsOption) *DuoApi {
+	opts := credentialsOptions{}
+	for _, o := range options {
+		o(&opts)
+	}
+	api := *duoapi
+	api.ikey = ikey
+	api.skey = newSecret(skey)
+	api.credentials = nil
+	if opts.separateLimiter && api.limiter != nil {
+		api.limiter = api.limiter.clone()
+	}
+	return &api
+}
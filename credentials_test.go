@@ -0,0 +1,164 @@
+package duoapi
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEnvCredentials(t *testing.T) {
+	provider := EnvCredentials("DUOAPI_TEST_IKEY", "DUOAPI_TEST_SKEY")
+	os.Setenv("DUOAPI_TEST_IKEY", "ikey-env")
+	defer os.Unsetenv("DUOAPI_TEST_IKEY")
+	os.Unsetenv("DUOAPI_TEST_SKEY")
+
+	if _, err := provider.Credentials(context.Background()); err == nil {
+		t.Fatal("Expected an error with DUOAPI_TEST_SKEY unset")
+	}
+
+	os.Setenv("DUOAPI_TEST_SKEY", "skey-env")
+	defer os.Unsetenv("DUOAPI_TEST_SKEY")
+	creds, err := provider.Credentials(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if creds.IKey != "ikey-env" || creds.SKey != "skey-env" {
+		t.Fatalf("Unexpected credentials %+v", creds)
+	}
+}
+
+func TestFileCredentialsRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "duo.json")
+	write := func(contents string, modTime time.Time) {
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now().Add(-time.Hour)
+	write(`{"ikey": "ikey-one", "skey": "skey-one"}`, start)
+
+	provider := FileCredentials(path)
+	creds, err := provider.Credentials(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if creds.SKey != "skey-one" {
+		t.Fatalf("Expected skey-one, but got %s", creds.SKey)
+	}
+
+	write(`{"ikey": "ikey-one", "skey": "skey-two"}`, start.Add(time.Minute))
+	creds, err = provider.Credentials(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if creds.SKey != "skey-two" {
+		t.Fatalf("Rotated key was not read: got %s", creds.SKey)
+	}
+
+	write(`not json`, start.Add(2*time.Minute))
+	if _, err := provider.Credentials(context.Background()); err == nil {
+		t.Fatal("Expected an error reading a malformed file")
+	}
+
+	// A rotation within the same modification time tick, to a key of the
+	// same length, is still read.
+	recent := time.Now().Truncate(time.Second)
+	write(`{"ikey": "ikey-one", "skey": "skey-333"}`, recent)
+	if creds, err = provider.Credentials(context.Background()); err != nil || creds.SKey != "skey-333" {
+		t.Fatalf("Unexpected credentials %v, %v", creds.SKey, err)
+	}
+	write(`{"ikey": "ikey-one", "skey": "skey-444"}`, recent)
+	if creds, err = provider.Credentials(context.Background()); err != nil || creds.SKey != "skey-444" {
+		t.Fatalf("Rotation within one modification time tick was missed: got %v, %v", creds.SKey, err)
+	}
+}
+
+func TestCredentialsProviderSignsRequests(t *testing.T) {
+	duo, mockHttp, _ := getMockClients([]http.Response{okResp})
+	duo.credentials = StaticCredentials("ikey-rotated", "skey-rotated")
+
+	params := url.Values{}
+	if _, _, err := duo.SignedCall("GET", "/auth/v2/check", params); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	req := mockHttp.actualRequests[0]
+	expected := sign("ikey-rotated", "skey-rotated", "GET", "host.baz",
+		"/auth/v2/check", req.Header.Get("Date"), params)
+	if req.Header.Get("Authorization") != expected {
+		t.Fatal("Request was not signed with the provider's credentials")
+	}
+}
+
+func TestWithCredentials(t *testing.T) {
+	duo, mockHttp, _ := getMockClients([]http.Response{okResp, okResp})
+	duo.credentials = StaticCredentials("ikey-rotated", "skey-rotated")
+	duo.skew = &clockSkew{}
+	duo.limiter = NewRateLimiter(RateLimitFailFast, RateLimit{Prefix: "/auth/", Rate: 1, Burst: 1})
+	other := duo.WithCredentials("ikey-other", "skey-other", SeparateRateLimiter)
+
+	params := url.Values{}
+	if _, _, err := other.SignedCall("GET", "/auth/v2/check", params); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	req := mockHttp.actualRequests[0]
+	expected := sign("ikey-other", "skey-other", "GET", "host.baz",
+		"/auth/v2/check", req.Header.Get("Date"), params)
+	if req.Header.Get("Authorization") != expected {
+		t.Fatal("Request was not signed with the new credentials")
+	}
+	if other.skew != duo.skew {
+		t.Error("Expected the clock skew to be shared")
+	}
+	if other.limiter == duo.limiter || len(other.limiter.limits) != 1 {
+		t.Error("Expected a rate limiter of its own, with the same limits")
+	}
+	if same := duo.WithCredentials("ikey-rotated", "skey-rotated"); same.limiter != duo.limiter {
+		t.Error("Expected the rate limiter to be shared without SeparateRateLimiter")
+	}
+
+	if _, _, err := duo.SignedCall("GET", "/auth/v2/check", params); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	req = mockHttp.actualRequests[1]
+	expected = sign("ikey-rotated", "skey-rotated", "GET", "host.baz",
+		"/auth/v2/check", req.Header.Get("Date"), params)
+	if req.Header.Get("Authorization") != expected {
+		t.Fatal("The original DuoApi's credentials changed")
+	}
+}
+
+type failingCredentials struct{}
+
+var errNoCredentials = errors.New("no credentials")
+
+func (failingCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	return Credentials{}, errNoCredentials
+}
+
+func TestCredentialsProviderError(t *testing.T) {
+	duo, mockHttp, _ := getMockClients(nil)
+	duo.credentials = failingCredentials{}
+
+	_, _, err := duo.SignedCall("GET", "/auth/v2/check", url.Values{})
+	if err != errNoCredentials {
+		t.Fatalf("Expected the provider's error, but got %v", err)
+	}
+	if len(mockHttp.actualRequests) != 0 {
+		t.Fatal("Sent a request without credentials")
+	}
+
+	duo = NewDuoApi("", "", "api-XXXXXXX.duosecurity.com", "go-client",
+		SetCredentialsProvider(failingCredentials{}))
+	if _, ok := duo.credentials.(failingCredentials); !ok {
+		t.Fatal("SetCredentialsProvider failed to set the provider")
+	}
+}
@@ -2,9 +2,12 @@ package duoapi
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strconv"
 	"strings"
@@ -154,6 +157,100 @@ func TestV2Canonicalize(t *testing.T) {
 	}
 }
 
+func TestV5Canonicalize(t *testing.T) {
+	values := url.Values{}
+	values.Set("realname", "First Last")
+	values.Set("username", "root")
+	headers := map[string]string{
+		"X-Duo-B":    "2",
+		"x-duo-a":    "1",
+		"User-Agent": "not signed",
+	}
+	canon := canonicalizeV5(
+		"PoSt",
+		"foO.BAr52.cOm",
+		"/Foo/BaR2/qux",
+		values,
+		"Tue, 04 Jul 2017 14:12:00",
+		"",
+		headers)
+	params := strings.Split(canon, "\n")
+	if len(params) != 7 {
+		t.Fatal("Expected 7 lines, but got " + strconv.Itoa(len(params)))
+	}
+	if params[4] != "realname=First%20Last&username=root" {
+		t.Error("Expected sorted escaped params, but got " + params[4])
+	}
+	emptyHash := "cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce" +
+		"47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e"
+	if params[5] != emptyHash {
+		t.Error("Expected the hash of an empty body, but got " + params[5])
+	}
+	headersHash := "8917173aab371897be6b5afa6c6f19477fc129df821ed80789bf50f929173c1b" +
+		"5bc81d98756541e632cd774bde121eb061ee7ef1ad7a8abfc60074d333324318"
+	if params[6] != headersHash {
+		t.Error("Expected the hash of the X-Duo headers, but got " + params[6])
+	}
+}
+
+func TestV5Sign(t *testing.T) {
+	values := url.Values{}
+	values.Set("realname", "First Last")
+	values.Set("username", "root")
+	res := signV5("test_ikey",
+		"gtdfxv9YgVBYcF6dl2Eq17KUQJN2PLM2ODVTkvoT",
+		"POST",
+		"foO.BAr52.cOm",
+		"/Foo/BaR2/qux",
+		"Tue, 04 Jul 2017 14:12:00",
+		values,
+		"",
+		map[string]string{"X-Duo-A": "1", "X-Duo-B": "2"})
+	if res != "Basic dGVzdF9pa2V5OjRiYjA5NTE4NDNkMzhkYzJhODYyY2Q1ZjkxNWU2MWE4Yzk0ZDRjMjQ1OTc2"+
+		"NjFmMjlmMmE2MDBhN2E4NGU2YzQ3MDE0YTQ4ZjNlMWJkMzI0MzJlNjIzNzYyZjAyNjgzZDA0NmVj"+
+		"YjBlODZjMmM3MGUxOTc2N2JjNTdlNTA3YWRl" {
+		t.Error("Unexpected form parameter signature " + res)
+	}
+
+	res = signV5("test_ikey",
+		"gtdfxv9YgVBYcF6dl2Eq17KUQJN2PLM2ODVTkvoT",
+		"POST",
+		"foO.BAr52.cOm",
+		"/Foo/BaR2/qux",
+		"Tue, 04 Jul 2017 14:12:00",
+		url.Values{},
+		`{"alpha":["a","b"],"bravo":"b"}`,
+		nil)
+	if res != "Basic dGVzdF9pa2V5OjAwNjA5ODNiYTIxNTlkZDk2ODUwMGIxNzRiZTMwMDdmNTRjZTY0OGUzMGM5"+
+		"OWUwYWQ5OTJjY2VhNzUzYmYzNjk0OGM5YjRiYWVhNmUzY2IxNDYzYTk4ZGQxMTc0NWY5NTFlMDdh"+
+		"NWE2ZmUwYzJiYmMwZmM2ZmQ0ODQwNmZjNzkz" {
+		t.Error("Unexpected JSON body signature " + res)
+	}
+}
+
+func TestSetSignatureVersion(t *testing.T) {
+	duo := NewDuoApi("ABC", "123", "api-XXXXXXX.duosecurity.com", "go-client")
+	if duo.sigVersion != SignatureV2 {
+		t.Fatal("Expected SignatureV2 by default")
+	}
+
+	httpClient := &mockHttpClient{responses: []http.Response{okResp}}
+	duo = NewDuoApi("ABC", "123", "api-XXXXXXX.duosecurity.com", "go-client",
+		SetSignatureVersion(SignatureV5))
+	duo.authClient = httpClient
+	params := url.Values{"username": []string{"root"}}
+	_, _, err := duo.SignedCall("POST", "/admin/v1/users", params)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	req := httpClient.actualRequests[0]
+	expected := signV5("ABC", "123", "POST", "api-XXXXXXX.duosecurity.com",
+		"/admin/v1/users", req.Header.Get("Date"), params, "", nil)
+	if req.Header.Get("Authorization") != expected {
+		t.Fatal("Request was not signed with SignatureV5")
+	}
+}
+
 func TestNewDuo(t *testing.T) {
 	duo := NewDuoApi("ABC", "123", "api-XXXXXXX.duosecurity.com", "go-client")
 	if duo == nil {
@@ -175,13 +272,45 @@ func TestSetTransport(t *testing.T) {
 	}
 }
 
+func TestSetBaseURL(t *testing.T) {
+	params := url.Values{"username": []string{"jsmith"}}
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/duo/auth/v2/preauth" {
+				t.Errorf("Unexpected path %s", r.URL.Path)
+			}
+			expected := sign("ABC", "123", "POST", "api-test.duosecurity.com",
+				"/auth/v2/preauth", r.Header.Get("Date"), params)
+			if r.Header.Get("Authorization") != expected {
+				t.Error("Request was not signed for the canonical host and URI")
+			}
+			fmt.Fprintln(w, `{"stat": "OK"}`)
+		}),
+	)
+	defer ts.Close()
+
+	base, err := url.Parse(ts.URL + "/duo/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	duo := NewDuoApi("ABC", "123", "api-test.duosecurity.com", "go-client", SetBaseURL(base))
+	result := &StatResult{}
+	err = duo.SignedCallInto(context.Background(), "POST", "/auth/v2/preauth", params, result)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Stat != "OK" {
+		t.Fatalf("Expected OK, but got %s", result.Stat)
+	}
+}
+
 func TestDupApiCallHttpErr(t *testing.T) {
 	httpClient := &mockHttpClient{doError: true}
 	sleepSvc := &mockSleepService{}
 
 	duo := &DuoApi{
 		ikey:       "ikey-foo",
-		skey:       "skey-bar",
+		skey:       newSecret("skey-bar"),
 		host:       "host.baz",
 		userAgent:  "ua-qux",
 		apiClient:  httpClient,
@@ -209,7 +338,7 @@ func getMockClients(httpResponses []http.Response) (*DuoApi, *mockHttpClient, *m
 
 	return &DuoApi{
 		ikey:       "ikey-foo",
-		skey:       "skey-bar",
+		skey:       newSecret("skey-bar"),
 		host:       "host.baz",
 		userAgent:  "ua-qux",
 		apiClient:  httpClient,
@@ -313,9 +442,141 @@ func TestSignedCallCompletelyRateLimited(t *testing.T) {
 		7, rateLimitResp, completeRateLimitSleepDurations)
 }
 
+func TestSignedCallRateLimitedResendsParams(t *testing.T) {
+	params := url.Values{}
+	params.Set("username", "root")
+	params.Set("realname", "First Last")
+
+	for _, method := range []string{"POST", "PUT", "DELETE"} {
+		responses := []http.Response{rateLimitResp, okResp}
+		duo, mockHttp, _ := getMockClients(responses)
+		resp, _, err := duo.SignedCall(method, "/v9/hello/world", params)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", method, err)
+		}
+		if resp.StatusCode != 200 {
+			t.Fatalf("%s: expected 200, but got %d", method, resp.StatusCode)
+		}
+		if len(mockHttp.actualRequests) != 2 {
+			t.Fatalf("%s: made %d requests instead of 2", method, len(mockHttp.actualRequests))
+		}
+
+		for i, req := range mockHttp.actualRequests {
+			var sent url.Values
+			if method == "DELETE" {
+				sent = req.URL.Query()
+				if mockHttp.actualBodies[i] != "" {
+					t.Errorf("%s attempt %d: unexpected body %q", method, i+1, mockHttp.actualBodies[i])
+				}
+			} else {
+				sent, err = url.ParseQuery(mockHttp.actualBodies[i])
+				if err != nil {
+					t.Fatalf("%s attempt %d: unparseable body: %v", method, i+1, err)
+				}
+			}
+			if sent.Encode() != params.Encode() {
+				t.Errorf("%s attempt %d: sent params %q instead of %q",
+					method, i+1, sent.Encode(), params.Encode())
+			}
+
+			expectedSig := sign("ikey-foo", "skey-bar", method, "host.baz",
+				"/v9/hello/world", req.Header.Get("Date"), params)
+			if req.Header.Get("Authorization") != expectedSig {
+				t.Errorf("%s attempt %d: signature does not match its Date header", method, i+1)
+			}
+		}
+	}
+}
+
+func TestJSONSignedCall(t *testing.T) {
+	responses := []http.Response{rateLimitResp, okResp}
+	duo, mockHttp, _ := getMockClients(responses)
+	query := url.Values{"limit": []string{"10"}}
+	body := map[string]interface{}{
+		"policy_name": "Contractors",
+		"sections":    map[string][]string{"allowed": {"push"}},
+	}
+	_, _, err := duo.JSONSignedCall("post", "/admin/v2/policies", query, body)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(mockHttp.actualRequests) != 2 {
+		t.Fatalf("Made %d requests instead of 2", len(mockHttp.actualRequests))
+	}
+
+	expectedBody := `{"policy_name":"Contractors","sections":{"allowed":["push"]}}`
+	for i, req := range mockHttp.actualRequests {
+		if mockHttp.actualBodies[i] != expectedBody {
+			t.Errorf("Attempt %d sent body %q", i+1, mockHttp.actualBodies[i])
+		}
+		if req.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Attempt %d sent Content-Type %q", i+1, req.Header.Get("Content-Type"))
+		}
+		if req.URL.RawQuery != "limit=10" {
+			t.Errorf("Attempt %d sent query %q", i+1, req.URL.RawQuery)
+		}
+		expectedSig := signV5("ikey-foo", "skey-bar", "POST", "host.baz",
+			"/admin/v2/policies", req.Header.Get("Date"), query, expectedBody, nil)
+		if req.Header.Get("Authorization") != expectedSig {
+			t.Errorf("Attempt %d was not signed with SignatureV5 over its body", i+1)
+		}
+	}
+}
+
+func TestJSONSignedCallUnencodableBody(t *testing.T) {
+	duo, mockHttp, _ := getMockClients(nil)
+	_, _, err := duo.JSONSignedCall("POST", "/admin/v2/policies", nil, make(chan int))
+	if err == nil {
+		t.Fatal("Expected an error encoding the body")
+	}
+	if len(mockHttp.actualRequests) != 0 {
+		t.Fatal("Sent a request with an unencodable body")
+	}
+}
+
+func TestSignedCallContextCanceledDuringBackoff(t *testing.T) {
+	responses := []http.Response{rateLimitResp, okResp}
+
+	duo, mockHttp, mockSleep := getMockClients(responses)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	resp, _, err := duo.SignedCallContext(ctx, "GET", "/v9/hello/world", url.Values{})
+	if err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, but got %v", err)
+	}
+	if resp != nil {
+		t.Fatal("Non nil response returned")
+	}
+	if len(mockHttp.actualRequests) != 1 {
+		t.Fatal("Made " + strconv.Itoa(len(mockHttp.actualRequests)) +
+			" requests instead of 1")
+	}
+	if len(mockSleep.sleepCalls) != 1 {
+		t.Fatal("Made " + strconv.Itoa(len(mockSleep.sleepCalls)) +
+			" sleep calls instead of 1")
+	}
+	if mockHttp.actualRequests[0].Context() != ctx {
+		t.Fatal("Request was not bound to the caller's context")
+	}
+}
+
+func TestTimeSleepServiceCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	err := timeSleepService{}.Sleep(ctx, time.Minute)
+	if err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, but got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("Sleep did not return promptly after cancellation")
+	}
+}
+
 type mockHttpClient struct {
 	responses      []http.Response
 	actualRequests []*http.Request
+	actualBodies   []string
 	doError        bool
 }
 
@@ -324,6 +585,13 @@ func (c *mockHttpClient) Do(req *http.Request) (*http.Response, error) {
 		c.actualRequests = []*http.Request{}
 	}
 	c.actualRequests = append(c.actualRequests, req)
+	// Drain the body, as a real client would.
+	body := ""
+	if req.Body != nil {
+		b, _ := ioutil.ReadAll(req.Body)
+		body = string(b)
+	}
+	c.actualBodies = append(c.actualBodies, body)
 	if c.doError {
 		return nil, errors.New("Ouch")
 	}
@@ -337,9 +605,109 @@ type mockSleepService struct {
 	sleepCalls []time.Duration
 }
 
-func (svc *mockSleepService) Sleep(duration time.Duration) {
+func (svc *mockSleepService) Sleep(ctx context.Context, duration time.Duration) error {
 	if svc.sleepCalls == nil {
 		svc.sleepCalls = []time.Duration{}
 	}
 	svc.sleepCalls = append(svc.sleepCalls, duration)
+	return ctx.Err()
+}
+
+func (svc *mockSleepService) SleepExact(ctx context.Context, duration time.Duration) error {
+	return svc.Sleep(ctx, duration)
+}
+
+func TestCheckResponse(t *testing.T) {
+	ok := &http.Response{StatusCode: 200}
+	if err := CheckResponse(ok, []byte(`{"stat": "OK", "response": {}}`)); err != nil {
+		t.Fatalf("Unexpected error for OK response: %v", err)
+	}
+	if err := CheckResponse(ok, []byte("\x89PNG")); err != nil {
+		t.Fatalf("Unexpected error for non-JSON 200 response: %v", err)
+	}
+
+	err := CheckResponse(ok, []byte(`{"stat": "FAIL", "code": 40401, "message": "Resource not found"}`))
+	if !IsNotFound(err) {
+		t.Fatalf("Expected a not found error, got %v", err)
+	}
+
+	err = CheckResponse(&http.Response{StatusCode: 502}, []byte("<html>Bad Gateway</html>"))
+	duoErr, isErr := err.(*Error)
+	if !isErr {
+		t.Fatalf("Expected an *Error, got %v", err)
+	}
+	if duoErr.StatusCode != 502 || duoErr.Message != "Bad Gateway" {
+		t.Errorf("Unexpected error contents: %+v", duoErr)
+	}
+}
+
+func TestErrorPredicates(t *testing.T) {
+	tests := []struct {
+		err      *Error
+		sentinel error
+		is       func(error) bool
+	}{
+		{&Error{StatusCode: 400, Code: 40002}, ErrInvalidParams, IsInvalidParams},
+		{&Error{StatusCode: 401, Code: 40103}, ErrUnauthorized, IsUnauthorized},
+		{&Error{StatusCode: 404, Code: 40401}, ErrNotFound, IsNotFound},
+		{&Error{StatusCode: 429, Code: 42901}, ErrRateLimited, IsRateLimited},
+		// The Duo code is consulted when the HTTP status is not conclusive.
+		{&Error{StatusCode: 200, Code: 40401}, ErrNotFound, IsNotFound},
+	}
+	for _, tt := range tests {
+		wrapped := fmt.Errorf("wrapped: %w", tt.err)
+		if !tt.is(wrapped) {
+			t.Errorf("Predicate did not match %v", tt.err)
+		}
+		if !errors.Is(wrapped, tt.sentinel) {
+			t.Errorf("errors.Is did not match %v against %v", tt.err, tt.sentinel)
+		}
+	}
+	if IsNotFound(&Error{StatusCode: 400, Code: 40002}) {
+		t.Error("IsNotFound matched an invalid parameters error")
+	}
+	if IsNotFound(errors.New("not found")) {
+		t.Error("IsNotFound matched a non-Duo error")
+	}
+}
+
+func TestWithHostAndParams(t *testing.T) {
+	duo, mockHttp, _ := getMockClients([]http.Response{okResp})
+	child := duo.WithHost("child.baz").WithParams(url.Values{"account_id": []string{"DA123"}})
+
+	params := url.Values{"username": []string{"jsmith"}, "account_id": []string{"DA999"}}
+	if _, _, err := child.SignedCall("POST", "/admin/v1/users", params); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	req := mockHttp.actualRequests[0]
+	sent := url.Values{"username": []string{"jsmith"}, "account_id": []string{"DA123"}}
+	if mockHttp.actualBodies[0] != sent.Encode() {
+		t.Errorf("Expected body %q, but got %q", sent.Encode(), mockHttp.actualBodies[0])
+	}
+	expected := sign("ikey-foo", "skey-bar", "POST", "child.baz", "/admin/v1/users", req.Header.Get("Date"), sent)
+	if req.Header.Get("Authorization") != expected {
+		t.Error("Request was not signed for the child host and parameters")
+	}
+	if params.Get("account_id") != "DA999" || duo.host != "host.baz" || duo.params != nil {
+		t.Error("WithHost or WithParams changed the original")
+	}
+}
+
+func TestFormBody(t *testing.T) {
+	duo, mockHttp, _ := getMockClients([]http.Response{okResp})
+
+	params := url.Values{"grant_type": []string{"authorization_code"}}
+	if _, _, err := duo.Call("POST", "/oauth/v1/token", params, FormBody); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	req := mockHttp.actualRequests[0]
+	if req.URL.RawQuery != "" || mockHttp.actualBodies[0] != params.Encode() {
+		t.Errorf("Expected the params in the body, but got query %q and body %q", req.URL.RawQuery, mockHttp.actualBodies[0])
+	}
+	if req.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		t.Errorf("Unexpected content type %q", req.Header.Get("Content-Type"))
+	}
+	if req.Header.Get("Authorization") != "" {
+		t.Error("Expected an unsigned call")
+	}
 }
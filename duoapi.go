@@ -1,20 +1,23 @@
 package duoapi
 
 import (
-	"crypto/hmac"
+	"bytes"
+	"context"
 	"crypto/sha1"
-	"crypto/tls"
+	"crypto/sha512"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"io"
-	"io/ioutil"
 	"math/rand"
 	"net/http"
 	"net/url"
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/duosecurity/duo_api_golang/internal/hmacsig"
 )
 
 const (
@@ -62,42 +65,164 @@ func sign(ikey string,
 	date string,
 	params url.Values) string {
 	canon := canonicalize(method, host, uri, params, date)
-	mac := hmac.New(sha1.New, []byte(skey))
-	mac.Write([]byte(canon))
-	sig := hex.EncodeToString(mac.Sum(nil))
+	sig := hmacsig.Hex(sha1.New, skey, canon)
+	auth := ikey + ":" + sig
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
+}
+
+// SignatureVersion selects how requests are canonicalized and signed.
+type SignatureVersion int
+
+const (
+	// SignatureV2 signs the date, method, host, path and parameters with
+	// HMAC-SHA1.  It is the default.
+	SignatureV2 SignatureVersion = 2
+	// SignatureV5 signs with HMAC-SHA512, and also covers a SHA-512 hash of
+	// the request body and of any X-Duo-* headers.  Endpoints that take a
+	// JSON body require it.
+	SignatureV5 SignatureVersion = 5
+)
+
+func hashSHA512(s string) string {
+	sum := sha512.Sum512([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// canonXDuoHeaders hashes the X-Duo-* headers among headers: their lower
+// cased names and values, sorted by name and joined with NUL bytes.
+func canonXDuoHeaders(headers map[string]string) string {
+	lowered := make(map[string]string)
+	names := []string{}
+	for name, value := range headers {
+		name = strings.ToLower(name)
+		if !strings.HasPrefix(name, "x-duo-") {
+			continue
+		}
+		lowered[name] = value
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	canon := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		canon = append(canon, name, lowered[name])
+	}
+	return hashSHA512(strings.Join(canon, "\x00"))
+}
+
+func canonicalizeV5(method string,
+	host string,
+	uri string,
+	params url.Values,
+	date string,
+	body string,
+	headers map[string]string) string {
+	var canon [7]string
+	canon[0] = date
+	canon[1] = strings.ToUpper(method)
+	canon[2] = strings.ToLower(host)
+	canon[3] = uri
+	canon[4] = canonParams(params)
+	canon[5] = hashSHA512(body)
+	canon[6] = canonXDuoHeaders(headers)
+	return strings.Join(canon[:], "\n")
+}
+
+func signV5(ikey string,
+	skey string,
+	method string,
+	host string,
+	uri string,
+	date string,
+	params url.Values,
+	body string,
+	headers map[string]string) string {
+	canon := canonicalizeV5(method, host, uri, params, date, body, headers)
+	sig := hmacsig.Hex(sha512.New, skey, canon)
 	auth := ikey + ":" + sig
 	return "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
 }
 
+// DuoApi makes signed calls to Duo's APIs.  Its mutable state, such as the
+// rate limiter and clock skew correction, is held behind pointers, so that
+// copies of a DuoApi, like those the API clients embed, share it.
 type DuoApi struct {
-	ikey       string
-	skey       string
-	host       string
-	userAgent  string
-	apiClient  httpClient
-	authClient httpClient
-	sleepSvc   sleepService
+	ikey        string
+	skey        *secret
+	host        string
+	userAgent   string
+	apiClient   httpClient
+	authClient  httpClient
+	sleepSvc    sleepService
+	retryPolicy RetryPolicy
+	sigVersion  SignatureVersion
+	middleware  []Middleware
+	limiter     *RateLimiter
+	credentials CredentialsProvider
+	skew        *clockSkew
+	baseURL     *url.URL
+	maxResponse int64
+	logger      Logger
+	observer    Observer
+	params      url.Values
+
+	logParamValues bool
 }
 
 type httpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 type sleepService interface {
-	Sleep(duration time.Duration)
+	Sleep(ctx context.Context, duration time.Duration) error
+	SleepExact(ctx context.Context, duration time.Duration) error
 }
 type timeSleepService struct{}
 
-func (svc timeSleepService) Sleep(duration time.Duration) {
-	time.Sleep(duration + (time.Duration(rand.Intn(1000)) * time.Millisecond))
+// Sleep waits for duration plus some jitter, returning early with the
+// context's error if ctx is done first.
+func (svc timeSleepService) Sleep(ctx context.Context, duration time.Duration) error {
+	return svc.SleepExact(ctx, duration+(time.Duration(rand.Intn(1000))*time.Millisecond))
+}
+
+// SleepExact is like Sleep, but without jitter.
+func (svc timeSleepService) SleepExact(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 type apiOptions struct {
-	timeout   time.Duration
-	insecure  bool
-	proxy     func(*http.Request) (*url.URL, error)
-	transport func(*http.Transport)
+	timeout     time.Duration
+	insecure    bool
+	proxy       func(*http.Request) (*url.URL, error)
+	transport   func(*http.Transport)
+	retryPolicy RetryPolicy
+	sigVersion  SignatureVersion
+	middleware  []Middleware
+	limiter     *RateLimiter
+	credentials CredentialsProvider
+	correctSkew bool
+	baseURL     *url.URL
+
+	maxResponseSize int64
+	logger          Logger
+	logParams       bool
+	observer        Observer
+
+	certPool          *x509.CertPool
+	appendSystemRoots bool
+	useSystemRoots    bool
+	pins              []string
 }
 
+// ApiOption is an optional parameter for NewDuoApi, such as SetTimeout(),
+// for code that collects options to pass on.
+type ApiOption = func(*apiOptions)
+
 // Optional parameter for NewDuoApi, used to configure timeouts on API calls.
 func SetTimeout(timeout time.Duration) func(*apiOptions) {
 	return func(opts *apiOptions) {
@@ -121,6 +246,19 @@ func SetProxy(proxy func(*http.Request) (*url.URL, error)) func(*apiOptions) {
 	}
 }
 
+// SetBaseURL is an optional parameter for NewDuoApi which sends calls to
+// base, e.g. a stand-in server for integration tests, instead of to
+// https://host.  base gives the scheme, host and port, and optionally a path
+// which is prefixed to every call's URI.  Requests are still signed for the
+// host given to NewDuoApi and the unprefixed URI.
+//
+// Example: duoapi.NewDuoApi(ikey,skey,host,userAgent,duoapi.SetBaseURL(&url.URL{Scheme: "http", Host: "localhost:8080"}))
+func SetBaseURL(base *url.URL) func(*apiOptions) {
+	return func(opts *apiOptions) {
+		opts.baseURL = base
+	}
+}
+
 // SetTransport enables additional control over the HTTP transport used to connect to the Duo API.
 func SetTransport(transport func(*http.Transport)) func(*apiOptions) {
 	return func(opts *apiOptions) {
@@ -128,6 +266,14 @@ func SetTransport(transport func(*http.Transport)) func(*apiOptions) {
 	}
 }
 
+// SetSignatureVersion is an optional parameter for NewDuoApi which selects
+// how SignedCall signs requests.  The default is SignatureV2.
+func SetSignatureVersion(version SignatureVersion) func(*apiOptions) {
+	return func(opts *apiOptions) {
+		opts.sigVersion = version
+	}
+}
+
 // Build an return a DuoApi struct.
 // ikey is your Duo integration key
 // skey is your Duo integration secret key
@@ -137,6 +283,18 @@ func SetTransport(transport func(*http.Transport)) func(*apiOptions) {
 //           appended to the userAgent.
 // options are optional parameters.  Use SetTimeout() to specify a timeout value
 //         for Rest API calls.  Use SetProxy() to specify proxy settings for Duo API calls.
+//         Use SetRetryPolicy() to change which failed calls are retried.
+//         Use SetSignatureVersion() to sign requests with HMAC-SHA512.
+//         Use SetMiddleware() to wrap every call attempt.
+//         Use SetRateLimiter() to keep calls within client-side budgets.
+//         Use SetCredentialsProvider() to supply keys which may be rotated.
+//         Use SetClockSkewCorrection() to correct for a drifting local clock.
+//         Use SetBaseURL() to send calls somewhere other than https://host.
+//         Use SetMaxResponseSize() to bound the size of responses.
+//         Use SetLogger() to record calls, retries and decode failures.
+//         Use SetObserver() to collect metrics about calls.
+//         Use SetCertPool(), AppendSystemRoots(), UseSystemRoots() and
+//         SetPinnedSPKI() to change how Duo's certificate is verified.
 //
 // Example: duoapi.NewDuoApi(ikey,skey,host,userAgent,duoapi.SetTimeout(10*time.Second))
 func NewDuoApi(ikey string,
@@ -144,26 +302,28 @@ func NewDuoApi(ikey string,
 	host string,
 	userAgent string,
 	options ...func(*apiOptions)) *DuoApi {
-	opts := apiOptions{proxy: http.ProxyFromEnvironment}
+	opts := apiOptions{
+		proxy:       http.ProxyFromEnvironment,
+		retryPolicy: DefaultRetryPolicy(),
+		sigVersion:  SignatureV2,
+	}
 	for _, o := range options {
 		o(&opts)
 	}
 
-	// Certificate pinning
-	certPool := x509.NewCertPool()
-	certPool.AppendCertsFromPEM([]byte(duoPinnedCert))
-
 	tr := &http.Transport{
-		Proxy: opts.proxy,
-		TLSClientConfig: &tls.Config{
-			RootCAs:            certPool,
-			InsecureSkipVerify: opts.insecure,
-		},
+		Proxy:           opts.proxy,
+		TLSClientConfig: buildTLSConfig(&opts),
 	}
 	if opts.transport != nil {
 		opts.transport(tr)
 	}
 
+	var skew *clockSkew
+	if opts.correctSkew {
+		skew = &clockSkew{}
+	}
+
 	if userAgent != "" {
 		userAgent += " "
 	}
@@ -171,7 +331,7 @@ func NewDuoApi(ikey string,
 
 	return &DuoApi{
 		ikey:      ikey,
-		skey:      skey,
+		skey:      newSecret(skey),
 		host:      host,
 		userAgent: userAgent,
 		apiClient: &http.Client{
@@ -181,12 +341,30 @@ func NewDuoApi(ikey string,
 		authClient: &http.Client{
 			Transport: tr,
 		},
-		sleepSvc: timeSleepService{},
+		sleepSvc:    timeSleepService{},
+		retryPolicy: opts.retryPolicy,
+		sigVersion:  opts.sigVersion,
+		middleware:  opts.middleware,
+		limiter:     opts.limiter,
+		credentials: opts.credentials,
+		skew:        skew,
+		baseURL:     opts.baseURL,
+		maxResponse: opts.maxResponseSize,
+		logger:      opts.logger,
+		observer:    opts.observer,
+
+		logParamValues: opts.logParams,
 	}
 }
 
 type requestOptions struct {
-	timeout bool
+	timeout  bool
+	attempts *int
+	stream   bool
+	meta     []*ResponseMeta
+	formBody bool
+
+	attemptParams func(params url.Values) error
 }
 
 type DuoApiOption func(*requestOptions)
@@ -196,6 +374,25 @@ func UseTimeout(opts *requestOptions) {
 	opts.timeout = true
 }
 
+// FormBody is passed to Call or CallContext to send the call's parameters
+// as a form encoded body, as OAuth endpoints expect, instead of in the URL.
+//
+// Example: duo.Call("POST", "/oauth/v1/token", params, duoapi.FormBody)
+func FormBody(opts *requestOptions) {
+	opts.formBody = true
+}
+
+// AttemptParams is passed to Call or CallContext with a function which adds
+// parameters to a copy of the call's for each attempt, for values which
+// must not be sent twice, such as a client assertion's jti.
+//
+// Example: duo.Call("POST", "/oauth/v1/token", params, duoapi.FormBody, duoapi.AttemptParams(addAssertion))
+func AttemptParams(fn func(params url.Values) error) DuoApiOption {
+	return func(opts *requestOptions) {
+		opts.attemptParams = fn
+	}
+}
+
 func (duoapi *DuoApi) buildOptions(options ...DuoApiOption) *requestOptions {
 	opts := &requestOptions{}
 	for _, o := range options {
@@ -206,12 +403,16 @@ func (duoapi *DuoApi) buildOptions(options ...DuoApiOption) *requestOptions {
 
 // API calls will return a StatResult object.  On success, Stat is 'OK'.
 // On error, Stat is 'FAIL', and Code, Message, and Message_Detail
-// contain error information.
+// contain error information.  The authapi and admin clients report such
+// failures as an *Error instead of returning the StatResult.
 type StatResult struct {
 	Stat           string
 	Code           *int32
 	Message        *string
 	Message_Detail *string
+	// Meta describes the HTTP response, when the result was returned by
+	// CallInto, SignedCallInto or JSONSignedCallInto.
+	Meta ResponseMeta `json:"-"`
 }
 
 // Make an unsigned Duo Rest API call.  See Duo's online documentation
@@ -227,17 +428,46 @@ func (duoapi *DuoApi) Call(method string,
 	uri string,
 	params url.Values,
 	options ...DuoApiOption) (*http.Response, []byte, error) {
+	return duoapi.CallContext(context.Background(), method, uri, params, options...)
+}
+
+// CallContext is like Call, but the request, and any rate limit backoff
+// between retries, is aborted when ctx is done.
+func (duoapi *DuoApi) CallContext(ctx context.Context,
+	method string,
+	uri string,
+	params url.Values,
+	options ...DuoApiOption) (*http.Response, []byte, error) {
 
-	url := url.URL{
-		Scheme:   "https",
-		Host:     duoapi.host,
-		Path:     uri,
-		RawQuery: params.Encode(),
-	}
+	params = duoapi.callParams(params)
+	opts := duoapi.buildOptions(options...)
 	headers := make(map[string]string)
 	headers["User-Agent"] = duoapi.userAgent
+	if opts.formBody {
+		headers["Content-Type"] = "application/x-www-form-urlencoded"
+	}
 
-	return duoapi.makeRetryableHttpCall(method, url, headers, nil, options...)
+	build := func(ctx context.Context) (*http.Request, error) {
+		attemptParams := params
+		if opts.attemptParams != nil {
+			attemptParams = url.Values{}
+			for k, v := range params {
+				attemptParams[k] = v
+			}
+			if err := opts.attemptParams(attemptParams); err != nil {
+				return nil, err
+			}
+		}
+		url := duoapi.requestURL(uri)
+		var requestBody []byte
+		if opts.formBody {
+			requestBody = []byte(attemptParams.Encode())
+		} else {
+			url.RawQuery = attemptParams.Encode()
+		}
+		return newRequest(ctx, method, url, headers, requestBody)
+	}
+	return duoapi.makeRetryableHttpCall(ctx, method, uri, params, build, options...)
 }
 
 // Make a signed Duo Rest API call.  See Duo's online documentation
@@ -253,39 +483,313 @@ func (duoapi *DuoApi) SignedCall(method string,
 	uri string,
 	params url.Values,
 	options ...DuoApiOption) (*http.Response, []byte, error) {
+	return duoapi.SignedCallContext(context.Background(), method, uri, params, options...)
+}
 
-	now := time.Now().UTC().Format(time.RFC1123Z)
-	auth_sig := sign(duoapi.ikey, duoapi.skey, method, duoapi.host, uri, now, params)
+// SignedCallContext is like SignedCall, but the request, and any rate limit
+// backoff between retries, is aborted when ctx is done.
+func (duoapi *DuoApi) SignedCallContext(ctx context.Context,
+	method string,
+	uri string,
+	params url.Values,
+	options ...DuoApiOption) (*http.Response, []byte, error) {
 
-	url := url.URL{
-		Scheme: "https",
-		Host:   duoapi.host,
-		Path:   uri,
-	}
+	params = duoapi.callParams(params)
+	url := duoapi.requestURL(uri)
 	method = strings.ToUpper(method)
 
-	if method == "GET" {
+	var requestBody []byte
+	if method == "POST" || method == "PUT" {
+		requestBody = []byte(params.Encode())
+	} else {
 		url.RawQuery = params.Encode()
 	}
 
-	headers := make(map[string]string)
-	headers["User-Agent"] = duoapi.userAgent
-	headers["Authorization"] = auth_sig
-	headers["Date"] = now
-	var requestBody io.ReadCloser = nil
-	if method == "POST" || method == "PUT" {
-		headers["Content-Type"] = "application/x-www-form-urlencoded"
-		requestBody = ioutil.NopCloser(strings.NewReader(params.Encode()))
+	// Every attempt is signed afresh, so a retry carries a current Date.
+	build := func(ctx context.Context) (*http.Request, error) {
+		now := duoapi.now().UTC().Format(time.RFC1123Z)
+		headers := make(map[string]string)
+		headers["User-Agent"] = duoapi.userAgent
+		auth_sig, err := duoapi.sign(ctx, duoapi.sigVersion, method, uri, now, params, "", headers)
+		if err != nil {
+			return nil, err
+		}
+		headers["Authorization"] = auth_sig
+		headers["Date"] = now
+		if requestBody != nil {
+			headers["Content-Type"] = "application/x-www-form-urlencoded"
+		}
+		return newRequest(ctx, method, url, headers, requestBody)
 	}
+	return duoapi.makeRetryableHttpCall(ctx, method, uri, params, build, options...)
+}
+
+// JSONSignedCall makes a signed call whose body is body encoded as JSON,
+// for endpoints which take structured parameters that url.Values cannot
+// express.  query is sent in the URL.  These calls are always signed with
+// SignatureV5, whatever the DuoApi's signature version; a nil body sends
+// no body.
+//
+// Example: duo.JSONSignedCall("POST", "/admin/v2/policies", nil, policy, duoapi.UseTimeout)
+func (duoapi *DuoApi) JSONSignedCall(method string,
+	uri string,
+	query url.Values,
+	body interface{},
+	options ...DuoApiOption) (*http.Response, []byte, error) {
 
-	return duoapi.makeRetryableHttpCall(method, url, headers, requestBody, options...)
+	return duoapi.JSONSignedCallContext(context.Background(), method, uri, query, body, options...)
 }
 
-func (duoapi *DuoApi) makeRetryableHttpCall(
+// JSONSignedCallContext is like JSONSignedCall, but the request, and any rate
+// limit backoff between retries, is aborted when ctx is done.
+func (duoapi *DuoApi) JSONSignedCallContext(ctx context.Context,
+	method string,
+	uri string,
+	query url.Values,
+	body interface{},
+	options ...DuoApiOption) (*http.Response, []byte, error) {
+
+	var requestBody []byte
+	if body != nil {
+		var err error
+		requestBody, err = json.Marshal(body)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	query = duoapi.callParams(query)
+	url := duoapi.requestURL(uri)
+	url.RawQuery = query.Encode()
+	method = strings.ToUpper(method)
+
+	build := func(ctx context.Context) (*http.Request, error) {
+		now := duoapi.now().UTC().Format(time.RFC1123Z)
+		headers := make(map[string]string)
+		headers["User-Agent"] = duoapi.userAgent
+		auth_sig, err := duoapi.sign(ctx, SignatureV5, method, uri, now, query, string(requestBody), headers)
+		if err != nil {
+			return nil, err
+		}
+		headers["Authorization"] = auth_sig
+		headers["Date"] = now
+		if requestBody != nil {
+			headers["Content-Type"] = "application/json"
+		}
+		return newRequest(ctx, method, url, headers, requestBody)
+	}
+	return duoapi.makeRetryableHttpCall(ctx, method, uri, query, build, options...)
+}
+
+// CallInto makes an unsigned call like CallContext and decodes the JSON
+// response into result. A failed call is reported as an *Error.
+func (duoapi *DuoApi) CallInto(ctx context.Context,
+	method string,
+	uri string,
+	params url.Values,
+	result interface{},
+	options ...DuoApiOption) error {
+	var meta ResponseMeta
+	resp, body, err := duoapi.CallContext(ctx, method, uri, params, withResponseMeta(&meta, options)...)
+	if err != nil {
+		return err
+	}
+	return duoapi.decodeInto(ctx, method, uri, resp, body, result, meta, options...)
+}
+
+// SignedCallInto makes a signed call like SignedCallContext and decodes the
+// JSON response into result. A failed call is reported as an *Error.
+//
+// Example: duo.SignedCallInto(ctx, "GET", "/auth/v2/check", nil, &result, duoapi.UseTimeout)
+func (duoapi *DuoApi) SignedCallInto(ctx context.Context,
+	method string,
+	uri string,
+	params url.Values,
+	result interface{},
+	options ...DuoApiOption) error {
+	var meta ResponseMeta
+	resp, body, err := duoapi.SignedCallContext(ctx, method, uri, params, withResponseMeta(&meta, options)...)
+	if err != nil {
+		return err
+	}
+	return duoapi.decodeInto(ctx, method, uri, resp, body, result, meta, options...)
+}
+
+// JSONSignedCallInto makes a call like JSONSignedCallContext and decodes the
+// JSON response into result. A failed call is reported as an *Error.
+func (duoapi *DuoApi) JSONSignedCallInto(ctx context.Context,
+	method string,
+	uri string,
+	query url.Values,
+	body interface{},
+	result interface{},
+	options ...DuoApiOption) error {
+	var meta ResponseMeta
+	resp, respBody, err := duoapi.JSONSignedCallContext(ctx, method, uri, query, body, withResponseMeta(&meta, options)...)
+	if err != nil {
+		return err
+	}
+	return duoapi.decodeInto(ctx, method, uri, resp, respBody, result, meta, options...)
+}
+
+// decodeInto decodes the response to a call into result, logging any
+// response which could not be decoded.  Results which embed StatResult are
+// given the response's meta.
+func (duoapi *DuoApi) decodeInto(ctx context.Context,
+	method string,
+	uri string,
+	resp *http.Response,
+	body []byte,
+	result interface{},
+	meta ResponseMeta,
+	options ...DuoApiOption) error {
+	var err error
+	if duoapi.buildOptions(options...).stream {
+		err = decodeStream(uri, resp, result)
+	} else {
+		err = decodeResult(uri, resp, body, result)
+	}
+	if _, ok := err.(*Error); err != nil && !ok {
+		duoapi.logDecodeError(ctx, method, uri, resp, err)
+	}
+	if setter, ok := result.(metaSetter); ok && err == nil {
+		setter.setResponseMeta(meta)
+	}
+	return err
+}
+
+func decodeResult(uri string, resp *http.Response, body []byte, result interface{}) error {
+	if err := CheckResponse(resp, body); err != nil {
+		// Report the API path, without any base URL prefix.
+		if e, ok := err.(*Error); ok {
+			e.Path = uri
+		}
+		return err
+	}
+	return json.Unmarshal(body, result)
+}
+
+// URL returns the URL a call to uri is sent to: https://host/uri, or under
+// the base URL given to SetBaseURL.
+func (duoapi *DuoApi) URL(uri string) *url.URL {
+	u := duoapi.requestURL(uri)
+	return &u
+}
+
+// WithHost returns a DuoApi which signs calls for, and sends them to, host,
+// but otherwise shares duoapi's configuration and state, e.g. to call the
+// API of a child account with its parent's keys.  If SetBaseURL was given,
+// calls are still sent there.
+func (duoapi *DuoApi) WithHost(host string) *DuoApi {
+	api := *duoapi
+	api.host = host
+	return &api
+}
+
+// WithParams returns a DuoApi which adds params to every call, in its query
+// or form body, but otherwise shares duoapi's configuration and state.
+// params replace any parameters of the same name given to a call.
+//
+// Example: child := duo.WithHost(apiHostname).WithParams(url.Values{"account_id": []string{accountID}})
+func (duoapi *DuoApi) WithParams(params url.Values) *DuoApi {
+	api := *duoapi
+	api.params = url.Values{}
+	for key, values := range duoapi.params {
+		api.params[key] = values
+	}
+	for key, values := range params {
+		api.params[key] = values
+	}
+	return &api
+}
+
+// callParams returns params with any parameters given to WithParams added,
+// leaving params unchanged.
+func (duoapi *DuoApi) callParams(params url.Values) url.Values {
+	if len(duoapi.params) == 0 {
+		return params
+	}
+	merged := url.Values{}
+	for key, values := range params {
+		merged[key] = values
+	}
+	for key, values := range duoapi.params {
+		merged[key] = values
+	}
+	return merged
+}
+
+// requestURL returns the URL to send a call to uri to.
+func (duoapi *DuoApi) requestURL(uri string) url.URL {
+	if duoapi.baseURL == nil {
+		return url.URL{
+			Scheme: "https",
+			Host:   duoapi.host,
+			Path:   uri,
+		}
+	}
+	return url.URL{
+		Scheme: duoapi.baseURL.Scheme,
+		User:   duoapi.baseURL.User,
+		Host:   duoapi.baseURL.Host,
+		Path:   strings.TrimSuffix(duoapi.baseURL.Path, "/") + uri,
+	}
+}
+
+// sign returns the Authorization header for a request, signed with the
+// DuoApi's current credentials.  body and headers are only covered by
+// SignatureV5.
+func (duoapi *DuoApi) sign(ctx context.Context,
+	version SignatureVersion,
+	method string,
+	uri string,
+	date string,
+	params url.Values,
+	body string,
+	headers map[string]string) (string, error) {
+	creds := Credentials{IKey: duoapi.ikey, SKey: duoapi.skey.get()}
+	if duoapi.credentials != nil {
+		var err error
+		creds, err = duoapi.credentials.Credentials(ctx)
+		if err != nil {
+			return "", err
+		}
+	}
+	if version == SignatureV5 {
+		return signV5(creds.IKey, creds.SKey, method, duoapi.host, uri, date, params, body, headers), nil
+	}
+	return sign(creds.IKey, creds.SKey, method, duoapi.host, uri, date, params), nil
+}
+
+// requestBuilder returns the request for one attempt of a call.  It is called
+// for every attempt, so that each one is sent with an unread body.
+type requestBuilder func(ctx context.Context) (*http.Request, error)
+
+func newRequest(ctx context.Context,
 	method string,
 	url url.URL,
 	headers map[string]string,
-	body io.ReadCloser,
+	body []byte) (*http.Request, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, url.String(), bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		request.Header.Set(k, v)
+	}
+	return request, nil
+}
+
+func (duoapi *DuoApi) makeRetryableHttpCall(
+	ctx context.Context,
+	method string,
+	uri string,
+	params url.Values,
+	buildRequest requestBuilder,
 	options ...DuoApiOption) (*http.Response, []byte, error) {
 
 	opts := duoapi.buildOptions(options...)
@@ -295,38 +799,103 @@ func (duoapi *DuoApi) makeRetryableHttpCall(
 		client = duoapi.apiClient
 	}
 
-	backoffMs := initialBackoffMS
-	for {
-		request, err := http.NewRequest(method, url.String(), nil)
-		if err != nil {
-			return nil, nil, err
-		}
+	policy := duoapi.retryPolicy
+	if policy == nil {
+		policy = DefaultRetryPolicy()
+	}
+
+	handler := duoapi.chain(send(client, duoapi.maxResponse))
 
-		if headers != nil {
-			for k, v := range headers {
-				request.Header.Set(k, v)
+	var endpoint string
+	if duoapi.observer != nil {
+		endpoint = EndpointTemplate(uri)
+		duoapi.observer.CallStarted(ctx, method, endpoint)
+	}
+
+	start := time.Now()
+	attempt := 1
+	rateLimited := 0
+	var slept time.Duration
+	done := func(resp *http.Response, body []byte, err error) (*http.Response, []byte, error) {
+		latency := time.Since(start)
+		duoapi.logCall(ctx, method, uri, params, attempt, latency, resp, err)
+		if resp != nil {
+			for _, meta := range opts.meta {
+				*meta = NewResponseMeta(resp, attempt, latency)
 			}
 		}
-		if body != nil {
-			request.Body = body
+		if duoapi.observer != nil {
+			stats := CallStats{
+				Method:      method,
+				Endpoint:    endpoint,
+				Attempts:    attempt,
+				Latency:     latency,
+				RateLimited: rateLimited,
+				RetrySleep:  slept,
+				Err:         err,
+			}
+			if resp != nil {
+				stats.Status = resp.StatusCode
+			}
+			duoapi.observer.CallFinished(ctx, stats)
+		}
+		return resp, body, err
+	}
+	for ; ; attempt++ {
+		if duoapi.limiter != nil {
+			if err := duoapi.limiter.wait(ctx, uri, duoapi.sleepSvc); err != nil {
+				return done(nil, nil, err)
+			}
 		}
 
-		resp, err := client.Do(request)
-		var body []byte
+		request, err := buildRequest(ctx)
 		if err != nil {
-			return resp, body, err
+			return done(nil, nil, err)
 		}
 
-		if backoffMs > maxBackoffMS || resp.StatusCode != rateLimitHttpCode {
-			body, err = ioutil.ReadAll(resp.Body)
-			resp.Body.Close()
-			return resp, body, err
+		sent := time.Now()
+		resp, body, err := handler(&Request{
+			Method:      method,
+			URI:         uri,
+			Params:      params,
+			Attempt:     attempt,
+			Stream:      opts.stream,
+			HTTPRequest: request,
+		})
+		if resp == nil && err == nil {
+			err = errNoResult
+		}
+		if resp != nil && resp.StatusCode == rateLimitHttpCode {
+			rateLimited++
+		}
+		if resp != nil && duoapi.skew != nil {
+			duoapi.skew.observe(sent, time.Now(), resp)
+		}
+		if opts.attempts != nil {
+			*opts.attempts = attempt
 		}
 
-		resp.Body.Close()
+		// The policy sees a response only if it was read in full.
+		policyResp := resp
+		if err != nil {
+			policyResp = nil
+		}
+		delay, retry := policy.Retry(attempt, time.Since(start), policyResp, err)
+		if !retry {
+			return done(resp, body, err)
+		}
+		duoapi.logRetry(ctx, method, uri, attempt, time.Since(sent), delay, resp, err)
+		if opts.stream && err == nil {
+			// The caller never sees this attempt's unread body.
+			resp.Body.Close()
+		}
 
-		duoapi.sleepSvc.Sleep(time.Millisecond * time.Duration(backoffMs))
-		backoffMs *= backoffFactor
+		sleepStart := time.Now()
+		err = duoapi.sleepSvc.Sleep(ctx, delay)
+		slept += time.Since(sleepStart)
+		if err != nil {
+			return done(nil, nil, err)
+		}
 	}
 }
 
@@ -0,0 +1,812 @@
+package duoapitest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/duosecurity/duo_api_golang/admin"
+)
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 300
+)
+
+// adminUser is a stored user and the IDs of the objects associated with it.
+type adminUser struct {
+	user   admin.User
+	groups map[string]bool
+	phones map[string]bool
+	tokens map[string]bool
+}
+
+// AdminAPI is an in-memory stand-in for the Duo Admin API.  It stores
+// users, groups, phones, hardware tokens, integrations and administrators,
+// and serves the endpoints admin.Client uses to read and modify them:
+// lists are paginated by limit and offset, and missing objects are
+// reported with Duo's error codes.
+//
+// Only this subset of the Admin API is served:
+//
+//	GET, POST         /admin/v1/users
+//	GET, POST, DELETE /admin/v1/users/:user_id
+//	GET, POST         /admin/v1/users/:user_id/groups
+//	DELETE            /admin/v1/users/:user_id/groups/:group_id
+//	GET               /admin/v1/users/:user_id/phones
+//	GET, POST         /admin/v1/users/:user_id/tokens
+//	GET               /admin/v1/groups
+//	GET               /admin/v2/groups/:group_id
+//	GET               /admin/v1/phones
+//	GET, DELETE       /admin/v1/phones/:phone_id
+//	GET               /admin/v1/tokens, /admin/v1/tokens/:token_id
+//	GET               /admin/v1/integrations, /admin/v1/integrations/:integration_key
+//	GET               /admin/v1/admins, /admin/v1/admins/:admin_id
+//
+// Other endpoints, such as U2F tokens, bypass codes, info, settings,
+// administrative units, logs and policies, are answered with HTTP 404.
+//
+// AdminAPI is an http.Handler, so it may be served by any http.Server;
+// NewAdminServer serves it with httptest.
+type AdminAPI struct {
+	creds  Credentials
+	routes []route
+
+	mu             sync.Mutex
+	users          map[string]*adminUser
+	groups         map[string]*admin.Group
+	phones         map[string]*admin.Phone
+	tokens         map[string]*admin.Token
+	integrations   map[string]*admin.Integration
+	administrators map[string]*admin.Administrator
+	rateLimited    int
+	nextID         int
+}
+
+// NewAdminAPI returns an empty AdminAPI which accepts requests signed with
+// creds.
+func NewAdminAPI(creds Credentials) *AdminAPI {
+	a := &AdminAPI{
+		creds:          creds,
+		users:          make(map[string]*adminUser),
+		groups:         make(map[string]*admin.Group),
+		phones:         make(map[string]*admin.Phone),
+		tokens:         make(map[string]*admin.Token),
+		integrations:   make(map[string]*admin.Integration),
+		administrators: make(map[string]*admin.Administrator),
+	}
+	a.routes = []route{
+		{http.MethodGet, "/admin/v1/users", a.listUsers},
+		{http.MethodPost, "/admin/v1/users", a.createUser},
+		{http.MethodGet, "/admin/v1/users/:id", a.getUser},
+		{http.MethodPost, "/admin/v1/users/:id", a.modifyUser},
+		{http.MethodDelete, "/admin/v1/users/:id", a.deleteUser},
+		{http.MethodGet, "/admin/v1/users/:id/groups", a.listUserGroups},
+		{http.MethodPost, "/admin/v1/users/:id/groups", a.addUserGroup},
+		{http.MethodDelete, "/admin/v1/users/:id/groups/:id", a.removeUserGroup},
+		{http.MethodGet, "/admin/v1/users/:id/phones", a.listUserPhones},
+		{http.MethodGet, "/admin/v1/users/:id/tokens", a.listUserTokens},
+		{http.MethodPost, "/admin/v1/users/:id/tokens", a.addUserToken},
+		{http.MethodGet, "/admin/v1/groups", a.listGroups},
+		{http.MethodGet, "/admin/v2/groups/:id", a.getGroup},
+		{http.MethodGet, "/admin/v1/phones", a.listPhones},
+		{http.MethodGet, "/admin/v1/phones/:id", a.getPhone},
+		{http.MethodDelete, "/admin/v1/phones/:id", a.deletePhone},
+		{http.MethodGet, "/admin/v1/tokens", a.listTokens},
+		{http.MethodGet, "/admin/v1/tokens/:id", a.getToken},
+		{http.MethodGet, "/admin/v1/integrations", a.listIntegrations},
+		{http.MethodGet, "/admin/v1/integrations/:id", a.getIntegration},
+		{http.MethodGet, "/admin/v1/admins", a.listAdministrators},
+		{http.MethodGet, "/admin/v1/admins/:id", a.getAdministrator},
+	}
+	return a
+}
+
+// AdminServer is an AdminAPI served by an httptest.Server.
+type AdminServer struct {
+	*httptest.Server
+	*AdminAPI
+}
+
+// NewAdminServer starts an AdminServer which accepts requests signed with
+// creds.  Close it when done.
+//
+// Example:
+//
+//	server := duoapitest.NewAdminServer(duoapitest.Credentials{IKey: ikey, SKey: skey})
+//	defer server.Close()
+//	client := admin.FromDuoApi(duoapi.NewDuoApi(ikey, skey, duoapitest.DefaultHost, "",
+//		duoapi.SetBaseURL(server.BaseURL())))
+func NewAdminServer(creds Credentials) *AdminServer {
+	api := NewAdminAPI(creds)
+	return &AdminServer{Server: httptest.NewServer(api), AdminAPI: api}
+}
+
+// BaseURL returns the server's URL, for duoapi.SetBaseURL.
+func (s *AdminServer) BaseURL() *url.URL {
+	u, _ := url.Parse(s.URL)
+	return u
+}
+
+// AddUser stores user, generating its UserID if empty, and returns it as
+// the API would.  The user is put in the existing groups, and associated
+// with the existing phones and tokens, named by the IDs in user.Groups,
+// user.Phones and user.Tokens.
+func (a *AdminAPI) AddUser(user admin.User) admin.User {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if user.UserID == "" {
+		user.UserID = a.newID("DU")
+	}
+	if user.Status == "" {
+		user.Status = "active"
+	}
+	stored := &adminUser{
+		groups: make(map[string]bool),
+		phones: make(map[string]bool),
+		tokens: make(map[string]bool),
+	}
+	for _, group := range user.Groups {
+		if a.groups[group.GroupID] != nil {
+			stored.groups[group.GroupID] = true
+		}
+	}
+	for _, phone := range user.Phones {
+		if a.phones[phone.PhoneID] != nil {
+			stored.phones[phone.PhoneID] = true
+		}
+	}
+	for _, token := range user.Tokens {
+		if a.tokens[token.TokenID] != nil {
+			stored.tokens[token.TokenID] = true
+		}
+	}
+	user.Groups, user.Phones, user.Tokens = nil, nil, nil
+	stored.user = user
+	a.users[user.UserID] = stored
+	return a.renderUser(stored)
+}
+
+// AddGroup stores group, generating its GroupID if empty, and returns it.
+func (a *AdminAPI) AddGroup(group admin.Group) admin.Group {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if group.GroupID == "" {
+		group.GroupID = a.newID("DG")
+	}
+	if group.Status == "" {
+		group.Status = "active"
+	}
+	a.groups[group.GroupID] = &group
+	return group
+}
+
+// AddPhone stores phone, generating its PhoneID if empty, and returns it
+// as the API would.  It is associated with the existing users named by the
+// IDs in phone.Users.
+func (a *AdminAPI) AddPhone(phone admin.Phone) admin.Phone {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if phone.PhoneID == "" {
+		phone.PhoneID = a.newID("DP")
+	}
+	for _, user := range phone.Users {
+		if stored := a.users[user.UserID]; stored != nil {
+			stored.phones[phone.PhoneID] = true
+		}
+	}
+	phone.Users = nil
+	a.phones[phone.PhoneID] = &phone
+	return a.renderPhone(&phone)
+}
+
+// AddToken stores token, generating its TokenID if empty, and returns it
+// as the API would.  It is associated with the existing users named by the
+// IDs in token.Users.
+func (a *AdminAPI) AddToken(token admin.Token) admin.Token {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if token.TokenID == "" {
+		token.TokenID = a.newID("DH")
+	}
+	for _, user := range token.Users {
+		if stored := a.users[user.UserID]; stored != nil {
+			stored.tokens[token.TokenID] = true
+		}
+	}
+	token.Users = nil
+	a.tokens[token.TokenID] = &token
+	return a.renderToken(&token)
+}
+
+// AddIntegration stores integration, generating its IntegrationKey and
+// SecretKey if empty, and returns it.
+func (a *AdminAPI) AddIntegration(integration admin.Integration) admin.Integration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if integration.IntegrationKey == "" {
+		integration.IntegrationKey = a.newID("DI")
+	}
+	if integration.SecretKey == "" {
+		integration.SecretKey = fmt.Sprintf("%040d", a.nextID)
+	}
+	a.integrations[integration.IntegrationKey] = &integration
+	return integration
+}
+
+// AddAdministrator stores administrator, generating its AdminID if empty,
+// and returns it.
+func (a *AdminAPI) AddAdministrator(administrator admin.Administrator) admin.Administrator {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if administrator.AdminID == "" {
+		administrator.AdminID = a.newID("DE")
+	}
+	if administrator.Created == 0 {
+		administrator.Created = uint64(time.Now().Unix())
+	}
+	a.administrators[administrator.AdminID] = &administrator
+	return administrator
+}
+
+// RateLimitNext answers the next n requests with HTTP 429 and Duo's rate
+// limit error, as if the account had made too many requests.
+func (a *AdminAPI) RateLimitNext(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rateLimited = n
+}
+
+// adminHandlerFunc handles a request whose parameters are params.  ids are
+// the object IDs from the request's path.  It is called with a.mu held.
+type adminHandlerFunc func(w http.ResponseWriter, params url.Values, ids []string)
+
+type route struct {
+	method  string
+	pattern string
+	handler adminHandlerFunc
+}
+
+// match reports whether path matches the route's pattern, and if so
+// returns the path's segments which matched its ":id" segments.
+func (rt route) match(path string) ([]string, bool) {
+	want := strings.Split(strings.Trim(rt.pattern, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(got) {
+		return nil, false
+	}
+	var ids []string
+	for i, segment := range want {
+		switch {
+		case segment == ":id" && got[i] != "":
+			ids = append(ids, got[i])
+		case segment != got[i]:
+			return nil, false
+		}
+	}
+	return ids, true
+}
+
+// ServeHTTP implements http.Handler.
+func (a *AdminAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var handler adminHandlerFunc
+	var ids []string
+	found := false
+	for _, rt := range a.routes {
+		if matched, ok := rt.match(r.URL.Path); ok {
+			found = true
+			if rt.method == r.Method {
+				handler, ids = rt.handler, matched
+				break
+			}
+		}
+	}
+	if !found {
+		writeError(w, errNotFound)
+		return
+	}
+	if handler == nil {
+		writeError(w, errMethodNotAllowed)
+		return
+	}
+
+	params, err := requestParams(r)
+	if err != nil {
+		writeError(w, invalidParams(err.Error()))
+		return
+	}
+	if e := a.creds.verify(r); e != nil {
+		writeError(w, *e)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.rateLimited > 0 {
+		a.rateLimited--
+		writeError(w, errRateLimited)
+		return
+	}
+	handler(w, params, ids)
+}
+
+// newID returns a new identifier in Duo's 20 character format.  a.mu must
+// be held.
+func (a *AdminAPI) newID(prefix string) string {
+	a.nextID++
+	return fmt.Sprintf("%s%018d", prefix, a.nextID)
+}
+
+// writePage writes the page of items selected by the limit and offset in
+// params, along with its pagination metadata.
+func writePage(w http.ResponseWriter, params url.Values, items []interface{}) {
+	limit, offset := defaultPageLimit, 0
+	if v := params.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, invalidParams("limit"))
+			return
+		}
+		limit = n
+		if limit > maxPageLimit {
+			limit = maxPageLimit
+		}
+	}
+	if v := params.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, invalidParams("offset"))
+			return
+		}
+		offset = n
+	}
+
+	metadata := map[string]interface{}{"total_objects": len(items)}
+	if offset > 0 {
+		prev := offset - limit
+		if prev < 0 {
+			prev = 0
+		}
+		metadata["prev_offset"] = prev
+	}
+	page := []interface{}{}
+	if offset < len(items) {
+		end := offset + limit
+		if end < len(items) {
+			metadata["next_offset"] = end
+		} else {
+			end = len(items)
+		}
+		page = items[offset:end]
+	}
+	writeJSONStatus(w, http.StatusOK, map[string]interface{}{
+		"stat":     "OK",
+		"response": page,
+		"metadata": metadata,
+	})
+}
+
+// sortedKeys returns the keys of set in order, which for generated IDs is
+// the order their objects were created in.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// renderUser returns user as the API represents it.  a.mu must be held.
+func (a *AdminAPI) renderUser(stored *adminUser) admin.User {
+	user := stored.user
+	user.Groups = []admin.Group{}
+	for _, id := range sortedKeys(stored.groups) {
+		user.Groups = append(user.Groups, *a.groups[id])
+	}
+	user.Phones = []admin.Phone{}
+	for _, id := range sortedKeys(stored.phones) {
+		user.Phones = append(user.Phones, *a.phones[id])
+	}
+	user.Tokens = []admin.Token{}
+	for _, id := range sortedKeys(stored.tokens) {
+		user.Tokens = append(user.Tokens, *a.tokens[id])
+	}
+	user.IsEnrolled = len(user.Phones) > 0 || len(user.Tokens) > 0
+	return user
+}
+
+// owners returns the users associated with the object whose ID is id by
+// the link set chosen by links, as they appear within that object.  a.mu
+// must be held.
+func (a *AdminAPI) owners(id string, links func(*adminUser) map[string]bool) []admin.User {
+	users := []admin.User{}
+	for _, userID := range a.sortedUserIDs() {
+		stored := a.users[userID]
+		if links(stored)[id] {
+			users = append(users, stored.user)
+		}
+	}
+	return users
+}
+
+func (a *AdminAPI) renderPhone(phone *admin.Phone) admin.Phone {
+	rendered := *phone
+	rendered.Users = a.owners(phone.PhoneID, func(u *adminUser) map[string]bool { return u.phones })
+	return rendered
+}
+
+func (a *AdminAPI) renderToken(token *admin.Token) admin.Token {
+	rendered := *token
+	rendered.Users = a.owners(token.TokenID, func(u *adminUser) map[string]bool { return u.tokens })
+	return rendered
+}
+
+func (a *AdminAPI) sortedUserIDs() []string {
+	ids := make([]string, 0, len(a.users))
+	for id := range a.users {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// userFields are the parameters accepted when creating or modifying a user.
+var userFields = []string{
+	"username", "alias1", "alias2", "alias3", "alias4",
+	"realname", "email", "status", "notes", "firstname", "lastname",
+}
+
+// setUserFields updates user from params, returning the error to respond
+// with if any is invalid.
+func setUserFields(user *admin.User, params url.Values) *apiError {
+	for _, field := range userFields {
+		if _, ok := params[field]; !ok {
+			continue
+		}
+		value := params.Get(field)
+		switch field {
+		case "username":
+			user.Username = value
+		case "alias1":
+			user.Alias1 = &value
+		case "alias2":
+			user.Alias2 = &value
+		case "alias3":
+			user.Alias3 = &value
+		case "alias4":
+			user.Alias4 = &value
+		case "realname":
+			user.RealName = &value
+		case "email":
+			user.Email = value
+		case "status":
+			switch value {
+			case "active", "bypass", "disabled":
+			default:
+				e := invalidParams("status")
+				return &e
+			}
+			user.Status = value
+		case "notes":
+			user.Notes = value
+		case "firstname":
+			user.FirstName = &value
+		case "lastname":
+			user.LastName = &value
+		}
+	}
+	return nil
+}
+
+// usernameTaken reports whether a user other than userID has username.
+// a.mu must be held.
+func (a *AdminAPI) usernameTaken(username, userID string) bool {
+	for id, stored := range a.users {
+		if id != userID && stored.user.Username == username {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *AdminAPI) listUsers(w http.ResponseWriter, params url.Values, ids []string) {
+	username := params.Get("username")
+	items := []interface{}{}
+	for _, id := range a.sortedUserIDs() {
+		stored := a.users[id]
+		if username == "" || stored.user.Username == username {
+			items = append(items, a.renderUser(stored))
+		}
+	}
+	writePage(w, params, items)
+}
+
+func (a *AdminAPI) createUser(w http.ResponseWriter, params url.Values, ids []string) {
+	if params.Get("username") == "" {
+		writeError(w, invalidParams("username"))
+		return
+	}
+	if a.usernameTaken(params.Get("username"), "") {
+		writeError(w, errDuplicate)
+		return
+	}
+	user := admin.User{
+		UserID:  a.newID("DU"),
+		Status:  "active",
+		Created: uint64(time.Now().Unix()),
+		Aliases: map[string]string{},
+	}
+	if e := setUserFields(&user, params); e != nil {
+		writeError(w, *e)
+		return
+	}
+	stored := &adminUser{
+		user:   user,
+		groups: make(map[string]bool),
+		phones: make(map[string]bool),
+		tokens: make(map[string]bool),
+	}
+	a.users[user.UserID] = stored
+	writeOK(w, a.renderUser(stored))
+}
+
+func (a *AdminAPI) getUser(w http.ResponseWriter, params url.Values, ids []string) {
+	stored := a.users[ids[0]]
+	if stored == nil {
+		writeError(w, errNotFound)
+		return
+	}
+	writeOK(w, a.renderUser(stored))
+}
+
+func (a *AdminAPI) modifyUser(w http.ResponseWriter, params url.Values, ids []string) {
+	stored := a.users[ids[0]]
+	if stored == nil {
+		writeError(w, errNotFound)
+		return
+	}
+	if _, ok := params["username"]; ok {
+		username := params.Get("username")
+		if username == "" {
+			writeError(w, invalidParams("username"))
+			return
+		}
+		if a.usernameTaken(username, ids[0]) {
+			writeError(w, errDuplicate)
+			return
+		}
+	}
+	user := stored.user
+	if e := setUserFields(&user, params); e != nil {
+		writeError(w, *e)
+		return
+	}
+	stored.user = user
+	writeOK(w, a.renderUser(stored))
+}
+
+func (a *AdminAPI) deleteUser(w http.ResponseWriter, params url.Values, ids []string) {
+	// Like Duo, deleting a user which does not exist succeeds.
+	delete(a.users, ids[0])
+	writeOK(w, "")
+}
+
+func (a *AdminAPI) listUserGroups(w http.ResponseWriter, params url.Values, ids []string) {
+	stored := a.users[ids[0]]
+	if stored == nil {
+		writeError(w, errNotFound)
+		return
+	}
+	items := []interface{}{}
+	for _, id := range sortedKeys(stored.groups) {
+		items = append(items, *a.groups[id])
+	}
+	writePage(w, params, items)
+}
+
+func (a *AdminAPI) addUserGroup(w http.ResponseWriter, params url.Values, ids []string) {
+	stored := a.users[ids[0]]
+	if stored == nil {
+		writeError(w, errNotFound)
+		return
+	}
+	groupID := params.Get("group_id")
+	if a.groups[groupID] == nil {
+		writeError(w, invalidParams("group_id"))
+		return
+	}
+	stored.groups[groupID] = true
+	writeOK(w, "")
+}
+
+func (a *AdminAPI) removeUserGroup(w http.ResponseWriter, params url.Values, ids []string) {
+	stored := a.users[ids[0]]
+	if stored == nil {
+		writeError(w, errNotFound)
+		return
+	}
+	delete(stored.groups, ids[1])
+	writeOK(w, "")
+}
+
+func (a *AdminAPI) listUserPhones(w http.ResponseWriter, params url.Values, ids []string) {
+	stored := a.users[ids[0]]
+	if stored == nil {
+		writeError(w, errNotFound)
+		return
+	}
+	items := []interface{}{}
+	for _, id := range sortedKeys(stored.phones) {
+		items = append(items, *a.phones[id])
+	}
+	writePage(w, params, items)
+}
+
+func (a *AdminAPI) listUserTokens(w http.ResponseWriter, params url.Values, ids []string) {
+	stored := a.users[ids[0]]
+	if stored == nil {
+		writeError(w, errNotFound)
+		return
+	}
+	items := []interface{}{}
+	for _, id := range sortedKeys(stored.tokens) {
+		items = append(items, *a.tokens[id])
+	}
+	writePage(w, params, items)
+}
+
+func (a *AdminAPI) addUserToken(w http.ResponseWriter, params url.Values, ids []string) {
+	stored := a.users[ids[0]]
+	if stored == nil {
+		writeError(w, errNotFound)
+		return
+	}
+	tokenID := params.Get("token_id")
+	if a.tokens[tokenID] == nil {
+		writeError(w, invalidParams("token_id"))
+		return
+	}
+	stored.tokens[tokenID] = true
+	writeOK(w, "")
+}
+
+func (a *AdminAPI) listGroups(w http.ResponseWriter, params url.Values, ids []string) {
+	keys := make([]string, 0, len(a.groups))
+	for id := range a.groups {
+		keys = append(keys, id)
+	}
+	sort.Strings(keys)
+	items := []interface{}{}
+	for _, id := range keys {
+		items = append(items, *a.groups[id])
+	}
+	writePage(w, params, items)
+}
+
+func (a *AdminAPI) getGroup(w http.ResponseWriter, params url.Values, ids []string) {
+	group := a.groups[ids[0]]
+	if group == nil {
+		writeError(w, errNotFound)
+		return
+	}
+	writeOK(w, *group)
+}
+
+func (a *AdminAPI) listPhones(w http.ResponseWriter, params url.Values, ids []string) {
+	keys := make([]string, 0, len(a.phones))
+	for id := range a.phones {
+		keys = append(keys, id)
+	}
+	sort.Strings(keys)
+	items := []interface{}{}
+	for _, id := range keys {
+		phone := a.phones[id]
+		if number := params.Get("number"); number != "" && phone.Number != number {
+			continue
+		}
+		if extension := params.Get("extension"); extension != "" && phone.Extension != extension {
+			continue
+		}
+		items = append(items, a.renderPhone(phone))
+	}
+	writePage(w, params, items)
+}
+
+func (a *AdminAPI) getPhone(w http.ResponseWriter, params url.Values, ids []string) {
+	phone := a.phones[ids[0]]
+	if phone == nil {
+		writeError(w, errNotFound)
+		return
+	}
+	writeOK(w, a.renderPhone(phone))
+}
+
+func (a *AdminAPI) deletePhone(w http.ResponseWriter, params url.Values, ids []string) {
+	delete(a.phones, ids[0])
+	for _, stored := range a.users {
+		delete(stored.phones, ids[0])
+	}
+	writeOK(w, "")
+}
+
+func (a *AdminAPI) listTokens(w http.ResponseWriter, params url.Values, ids []string) {
+	typ, serial := params.Get("type"), params.Get("serial")
+	if (typ == "") != (serial == "") {
+		writeError(w, invalidParams("type and serial must be given together"))
+		return
+	}
+	keys := make([]string, 0, len(a.tokens))
+	for id := range a.tokens {
+		keys = append(keys, id)
+	}
+	sort.Strings(keys)
+	items := []interface{}{}
+	for _, id := range keys {
+		token := a.tokens[id]
+		if typ != "" && (token.Type != typ || token.Serial != serial) {
+			continue
+		}
+		items = append(items, a.renderToken(token))
+	}
+	writePage(w, params, items)
+}
+
+func (a *AdminAPI) getToken(w http.ResponseWriter, params url.Values, ids []string) {
+	token := a.tokens[ids[0]]
+	if token == nil {
+		writeError(w, errNotFound)
+		return
+	}
+	writeOK(w, a.renderToken(token))
+}
+
+func (a *AdminAPI) listIntegrations(w http.ResponseWriter, params url.Values, ids []string) {
+	keys := make([]string, 0, len(a.integrations))
+	for id := range a.integrations {
+		keys = append(keys, id)
+	}
+	sort.Strings(keys)
+	items := []interface{}{}
+	for _, id := range keys {
+		items = append(items, *a.integrations[id])
+	}
+	writePage(w, params, items)
+}
+
+func (a *AdminAPI) getIntegration(w http.ResponseWriter, params url.Values, ids []string) {
+	integration := a.integrations[ids[0]]
+	if integration == nil {
+		writeError(w, errNotFound)
+		return
+	}
+	writeOK(w, *integration)
+}
+
+func (a *AdminAPI) listAdministrators(w http.ResponseWriter, params url.Values, ids []string) {
+	keys := make([]string, 0, len(a.administrators))
+	for id := range a.administrators {
+		keys = append(keys, id)
+	}
+	sort.Strings(keys)
+	items := []interface{}{}
+	for _, id := range keys {
+		items = append(items, *a.administrators[id])
+	}
+	writePage(w, params, items)
+}
+
+func (a *AdminAPI) getAdministrator(w http.ResponseWriter, params url.Values, ids []string) {
+	administrator := a.administrators[ids[0]]
+	if administrator == nil {
+		writeError(w, errNotFound)
+		return
+	}
+	writeOK(w, *administrator)
+}
@@ -0,0 +1,247 @@
+package duoapitest_test
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	duoapi "github.com/duosecurity/duo_api_golang"
+	"github.com/duosecurity/duo_api_golang/admin"
+	"github.com/duosecurity/duo_api_golang/duoapitest"
+)
+
+func newAdminClient(server *duoapitest.AdminServer) *admin.Client {
+	return admin.New(*duoapi.NewDuoApi(testCreds.IKey, testCreds.SKey, duoapitest.DefaultHost, "GoTestClient",
+		duoapi.SetBaseURL(server.BaseURL()),
+		duoapi.SetRetryPolicy(&duoapi.BackoffPolicy{
+			InitialBackoff:   time.Millisecond,
+			Factor:           2,
+			MaxBackoff:       4 * time.Millisecond,
+			RetryStatusCodes: []int{429},
+		})))
+}
+
+func TestAdminServerUserLifecycle(t *testing.T) {
+	server := duoapitest.NewAdminServer(testCreds)
+	defer server.Close()
+	client := newAdminClient(server)
+
+	created, err := client.CreateUser(url.Values{"username": {"jsmith"}, "email": {"jsmith@example.com"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	userID := created.Response.UserID
+	if userID == "" || created.Response.Status != "active" {
+		t.Fatalf("Unexpected user %+v", created.Response)
+	}
+
+	if _, err := client.CreateUser(url.Values{"username": {"jsmith"}}); !duoapi.IsInvalidParams(err) {
+		t.Fatalf("Expected an invalid params error for a duplicate username, but got %v", err)
+	}
+
+	modified, err := client.ModifyUser(userID, url.Values{"status": {"disabled"}, "realname": {"J Smith"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if modified.Response.Status != "disabled" || *modified.Response.RealName != "J Smith" ||
+		modified.Response.Email != "jsmith@example.com" {
+		t.Fatalf("Unexpected modified user %+v", modified.Response)
+	}
+
+	fetched, err := client.GetUser(userID)
+	if err != nil || fetched.Response.Status != "disabled" {
+		t.Fatalf("Unexpected user %+v, %v", fetched, err)
+	}
+
+	if _, err := client.DeleteUser(userID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	_, err = client.GetUser(userID)
+	if !duoapi.IsNotFound(err) {
+		t.Fatalf("Expected a not found error, but got %v", err)
+	}
+	if code := err.(*duoapi.Error).Code; code != 40401 {
+		t.Errorf("Expected code 40401, but got %d", code)
+	}
+}
+
+func TestAdminServerPagination(t *testing.T) {
+	server := duoapitest.NewAdminServer(testCreds)
+	defer server.Close()
+	client := newAdminClient(server)
+
+	for i := 0; i < 250; i++ {
+		server.AddUser(admin.User{Username: fmt.Sprintf("user%03d", i)})
+	}
+
+	page, err := client.GetUsers(admin.Limit(100), admin.Offset(200))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(page.Response) != 50 {
+		t.Errorf("Expected 50 users, but got %d", len(page.Response))
+	}
+	metadata := page.Metadata
+	if metadata.TotalObjects != "250" || metadata.PrevOffset != "100" || metadata.NextOffset != "" {
+		t.Errorf("Unexpected metadata %+v", metadata)
+	}
+
+	first, err := client.GetUsers(admin.Limit(100))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if first.Metadata.NextOffset != "100" || first.Metadata.PrevOffset != "" {
+		t.Errorf("Unexpected metadata %+v", first.Metadata)
+	}
+
+	all, err := client.GetUsers()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(all.Response) != 250 {
+		t.Fatalf("Expected all 250 users, but got %d", len(all.Response))
+	}
+	seen := make(map[string]bool)
+	for _, user := range all.Response {
+		seen[user.UserID] = true
+	}
+	if len(seen) != 250 {
+		t.Errorf("Expected 250 distinct users, but got %d", len(seen))
+	}
+}
+
+func TestAdminServerAssociations(t *testing.T) {
+	server := duoapitest.NewAdminServer(testCreds)
+	defer server.Close()
+	client := newAdminClient(server)
+
+	group := server.AddGroup(admin.Group{Name: "Engineering"})
+	user := server.AddUser(admin.User{Username: "jsmith"})
+	phone := server.AddPhone(admin.Phone{Number: "+15555550100", Users: []admin.User{{UserID: user.UserID}}})
+	token := server.AddToken(admin.Token{Type: "h6", Serial: "123456"})
+
+	if _, err := client.AssociateGroupWithUser(user.UserID, group.GroupID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.AssociateUserToken(user.UserID, token.TokenID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	fetched, err := client.GetUser(user.UserID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(fetched.Response.Groups) != 1 || fetched.Response.Groups[0].Name != "Engineering" ||
+		len(fetched.Response.Phones) != 1 || len(fetched.Response.Tokens) != 1 || !fetched.Response.IsEnrolled {
+		t.Fatalf("Unexpected user %+v", fetched.Response)
+	}
+
+	fetchedPhone, err := client.GetPhone(phone.PhoneID)
+	if err != nil || len(fetchedPhone.Response.Users) != 1 || fetchedPhone.Response.Users[0].Username != "jsmith" {
+		t.Fatalf("Unexpected phone %+v, %v", fetchedPhone, err)
+	}
+	phones, err := client.GetPhones(admin.GetPhonesNumber("+15555550100"))
+	if err != nil || len(phones.Response) != 1 {
+		t.Fatalf("Unexpected phones %+v, %v", phones, err)
+	}
+	tokens, err := client.GetTokens(admin.GetTokensTypeAndSerial("h6", "123456"))
+	if err != nil || len(tokens.Response) != 1 || tokens.Response[0].Users[0].UserID != user.UserID {
+		t.Fatalf("Unexpected tokens %+v, %v", tokens, err)
+	}
+
+	if _, err := client.DisassociateGroupFromUser(user.UserID, group.GroupID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.DeletePhone(phone.PhoneID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	groups, err := client.GetUserGroups(user.UserID)
+	if err != nil || len(groups.Response) != 0 {
+		t.Fatalf("Unexpected groups %+v, %v", groups, err)
+	}
+	userPhones, err := client.GetUserPhones(user.UserID)
+	if err != nil || len(userPhones.Response) != 0 {
+		t.Fatalf("Unexpected phones %+v, %v", userPhones, err)
+	}
+	if _, err := client.GetPhone(phone.PhoneID); !duoapi.IsNotFound(err) {
+		t.Fatalf("Expected a not found error, but got %v", err)
+	}
+	if _, err := client.GetUserGroups("DUNOTAREALUSER000000"); !duoapi.IsNotFound(err) {
+		t.Fatalf("Expected a not found error, but got %v", err)
+	}
+}
+
+func TestAdminServerOtherObjects(t *testing.T) {
+	server := duoapitest.NewAdminServer(testCreds)
+	defer server.Close()
+	client := newAdminClient(server)
+
+	integration := server.AddIntegration(admin.Integration{Name: "Web SSO", Type: "websdk"})
+	administrator := server.AddAdministrator(admin.Administrator{Name: "Ada", Email: "ada@example.com", Role: "Owner"})
+	group := server.AddGroup(admin.Group{Name: "Ops"})
+
+	integrations, err := client.GetIntegrations()
+	if err != nil || len(integrations.Response) != 1 || integrations.Response[0].SecretKey == "" {
+		t.Fatalf("Unexpected integrations %+v, %v", integrations, err)
+	}
+	fetchedIntegration, err := client.GetIntegration(integration.IntegrationKey)
+	if err != nil || fetchedIntegration.Response.Name != "Web SSO" {
+		t.Fatalf("Unexpected integration %+v, %v", fetchedIntegration, err)
+	}
+	administrators, err := client.GetAdministrators()
+	if err != nil || len(administrators.Response) != 1 {
+		t.Fatalf("Unexpected administrators %+v, %v", administrators, err)
+	}
+	fetchedAdministrator, err := client.GetAdministrator(administrator.AdminID)
+	if err != nil || fetchedAdministrator.Response.Email != "ada@example.com" {
+		t.Fatalf("Unexpected administrator %+v, %v", fetchedAdministrator, err)
+	}
+	fetchedGroup, err := client.GetGroup(group.GroupID)
+	if err != nil || fetchedGroup.Response.Name != "Ops" {
+		t.Fatalf("Unexpected group %+v, %v", fetchedGroup, err)
+	}
+
+	if _, err := client.GetIntegration("DINOTAREALKEY0000000"); !duoapi.IsNotFound(err) {
+		t.Fatalf("Expected a not found error, but got %v", err)
+	}
+	if _, err := client.GetToken("DHNOTAREALTOKEN00000"); !duoapi.IsNotFound(err) {
+		t.Fatalf("Expected a not found error, but got %v", err)
+	}
+}
+
+func TestAdminServerRateLimit(t *testing.T) {
+	server := duoapitest.NewAdminServer(testCreds)
+	defer server.Close()
+	server.AddUser(admin.User{Username: "jsmith"})
+
+	noRetries := admin.New(*duoapi.NewDuoApi(testCreds.IKey, testCreds.SKey, duoapitest.DefaultHost, "GoTestClient",
+		duoapi.SetBaseURL(server.BaseURL()),
+		duoapi.SetRetryPolicy(&duoapi.BackoffPolicy{})))
+	server.RateLimitNext(1)
+	_, err := noRetries.GetUsers(admin.Limit(10))
+	if !duoapi.IsRateLimited(err) {
+		t.Fatalf("Expected a rate limited error, but got %v", err)
+	}
+	if code := err.(*duoapi.Error).Code; code != 42901 {
+		t.Errorf("Expected code 42901, but got %d", code)
+	}
+
+	// A client which retries rate limited calls gets through.
+	server.RateLimitNext(1)
+	users, err := newAdminClient(server).GetUsers(admin.Limit(10))
+	if err != nil || len(users.Response) != 1 {
+		t.Fatalf("Unexpected users %+v, %v", users, err)
+	}
+}
+
+func TestAdminServerRejectsBadSignature(t *testing.T) {
+	server := duoapitest.NewAdminServer(testCreds)
+	defer server.Close()
+	client := admin.New(*duoapi.NewDuoApi(testCreds.IKey, "not-the-secret-key", duoapitest.DefaultHost, "GoTestClient",
+		duoapi.SetBaseURL(server.BaseURL())))
+
+	if _, err := client.GetUsers(); !duoapi.IsUnauthorized(err) {
+		t.Fatalf("Expected an unauthorized error, but got %v", err)
+	}
+}
@@ -0,0 +1,390 @@
+package duoapitest
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outcome scripts how an AuthServer answers a user's authentication.
+type Outcome int
+
+const (
+	// Allow approves the authentication.
+	Allow Outcome = iota
+	// Deny rejects it, as if the user declined the request.
+	Deny
+	// Timeout rejects it as if the user never responded.
+	Timeout
+	// EnrollRequired has preauth tell the caller to enroll the user, and
+	// denies any authentication.
+	EnrollRequired
+	// WaitThenAllow approves the authentication, but an asynchronous one is
+	// reported as waiting to the user's first WaitPolls auth_status calls.
+	WaitThenAllow
+)
+
+// User is a user known to an AuthServer.
+type User struct {
+	Username string
+	// UserID is generated by AddUser if empty.
+	UserID  string
+	Outcome Outcome
+	// WaitPolls is how many auth_status calls a WaitThenAllow
+	// authentication is reported as waiting for; at least 1.
+	WaitPolls int
+}
+
+type transaction struct {
+	user  User
+	polls int
+}
+
+type enrollment struct {
+	user       User
+	expiration time.Time
+	complete   bool
+}
+
+// AuthServer is an in-process stand-in for the Duo Auth API, serving
+// /auth/v2/ping, check, logo, enroll, enroll_status, preauth, auth and
+// auth_status.  Users unknown to it must enroll.
+type AuthServer struct {
+	*httptest.Server
+	creds Credentials
+
+	mu          sync.Mutex
+	users       map[string]*User
+	txns        map[string]*transaction
+	enrollments map[string]*enrollment
+	logo        []byte
+	nextID      int
+}
+
+// NewAuthServer starts an AuthServer which accepts requests signed with
+// creds.  Close it when done.
+//
+// Example:
+//
+//	server := duoapitest.NewAuthServer(duoapitest.Credentials{IKey: ikey, SKey: skey})
+//	defer server.Close()
+//	api := authapi.FromDuoApi(duoapi.NewDuoApi(ikey, skey, duoapitest.DefaultHost, "",
+//		duoapi.SetBaseURL(server.BaseURL())))
+func NewAuthServer(creds Credentials) *AuthServer {
+	s := &AuthServer{
+		creds:       creds,
+		users:       make(map[string]*User),
+		txns:        make(map[string]*transaction),
+		enrollments: make(map[string]*enrollment),
+		logo:        defaultLogo(),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/v2/ping", s.handle(http.MethodGet, false, s.ping))
+	mux.HandleFunc("/auth/v2/check", s.handle(http.MethodGet, true, s.ping))
+	mux.HandleFunc("/auth/v2/logo", s.handle(http.MethodGet, true, s.serveLogo))
+	mux.HandleFunc("/auth/v2/enroll", s.handle(http.MethodPost, true, s.enroll))
+	mux.HandleFunc("/auth/v2/enroll_status", s.handle(http.MethodPost, true, s.enrollStatus))
+	mux.HandleFunc("/auth/v2/preauth", s.handle(http.MethodPost, true, s.preauth))
+	mux.HandleFunc("/auth/v2/auth", s.handle(http.MethodPost, true, s.auth))
+	mux.HandleFunc("/auth/v2/auth_status", s.handle(http.MethodGet, true, s.authStatus))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, errNotFound)
+	})
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// BaseURL returns the server's URL, for duoapi.SetBaseURL.
+func (s *AuthServer) BaseURL() *url.URL {
+	u, _ := url.Parse(s.URL)
+	return u
+}
+
+// AddUser adds user, or replaces the user with the same username, and
+// returns it with its UserID set.
+func (s *AuthServer) AddUser(user User) User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if user.UserID == "" {
+		user.UserID = s.newID("DU")
+	}
+	for name, existing := range s.users {
+		if existing.UserID == user.UserID && name != user.Username {
+			delete(s.users, name)
+		}
+	}
+	s.users[user.Username] = &user
+	return user
+}
+
+// CompleteEnrollment marks the pending enrollment of the user with userID
+// as successful, as if they had scanned its barcode.  The user is then
+// known to the server, with the Allow outcome.
+func (s *AuthServer) CompleteEnrollment(userID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.enrollments {
+		if e.user.UserID == userID && !e.complete {
+			e.complete = true
+			user := e.user
+			s.users[user.Username] = &user
+			return true
+		}
+	}
+	return false
+}
+
+// SetLogo sets the PNG served by /auth/v2/logo.  With nil, the logo
+// endpoint responds that there is no logo.
+func (s *AuthServer) SetLogo(png []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logo = png
+}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request, params url.Values)
+
+// handle wraps h to check the request's method and, if signed is set, its
+// signature.
+func (s *AuthServer) handle(method string, signed bool, h handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+		params, err := requestParams(r)
+		if err != nil {
+			writeError(w, invalidParams(err.Error()))
+			return
+		}
+		if signed {
+			if e := s.creds.verify(r); e != nil {
+				writeError(w, *e)
+				return
+			}
+		}
+		h(w, r, params)
+	}
+}
+
+// newID returns a new identifier in Duo's 20 character format.  s.mu must
+// be held.
+func (s *AuthServer) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s%018d", prefix, s.nextID)
+}
+
+// lookup returns the user named by params, or nil.  s.mu must be held.
+func (s *AuthServer) lookup(params url.Values) *User {
+	if username := params.Get("username"); username != "" {
+		return s.users[username]
+	}
+	userID := params.Get("user_id")
+	for _, user := range s.users {
+		if user.UserID == userID {
+			return user
+		}
+	}
+	return nil
+}
+
+func (s *AuthServer) ping(w http.ResponseWriter, r *http.Request, params url.Values) {
+	writeOK(w, map[string]interface{}{"time": time.Now().Unix()})
+}
+
+func (s *AuthServer) serveLogo(w http.ResponseWriter, r *http.Request, params url.Values) {
+	s.mu.Lock()
+	logo := s.logo
+	s.mu.Unlock()
+	if logo == nil {
+		writeError(w, errNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(logo)
+}
+
+func (s *AuthServer) enroll(w http.ResponseWriter, r *http.Request, params url.Values) {
+	validSecs := int64(86400)
+	if v := params.Get("valid_secs"); v != "" {
+		secs, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || secs <= 0 {
+			writeError(w, invalidParams("valid_secs"))
+			return
+		}
+		validSecs = secs
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	username := params.Get("username")
+	if username == "" {
+		username = fmt.Sprintf("user%d", s.nextID+1)
+	}
+	if _, ok := s.users[username]; ok {
+		writeError(w, invalidParams("username"))
+		return
+	}
+	user := User{Username: username, UserID: s.newID("DU"), Outcome: Allow}
+	code := s.newID("")
+	expiration := time.Now().Add(time.Duration(validSecs) * time.Second)
+	s.enrollments[code] = &enrollment{user: user, expiration: expiration}
+
+	writeOK(w, map[string]interface{}{
+		"activation_barcode": fmt.Sprintf("https://%s/frame/qr?value=%s", s.creds.host(), code),
+		"activation_code":    "duo://" + code,
+		"expiration":         expiration.Unix(),
+		"user_id":            user.UserID,
+		"username":           user.Username,
+	})
+}
+
+func (s *AuthServer) enrollStatus(w http.ResponseWriter, r *http.Request, params url.Values) {
+	code := strings.TrimPrefix(params.Get("activation_code"), "duo://")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.enrollments[code]
+	switch {
+	case !ok || e.user.UserID != params.Get("user_id"):
+		writeOK(w, "invalid")
+	case e.complete:
+		writeOK(w, "success")
+	case time.Now().After(e.expiration):
+		writeOK(w, "invalid")
+	default:
+		writeOK(w, "waiting")
+	}
+}
+
+var defaultDevices = []map[string]interface{}{{
+	"device":       "DPFZRS9FB0D46QFTM899",
+	"type":         "phone",
+	"number":       "XXX-XXX-0100",
+	"name":         "",
+	"capabilities": []string{"auto", "push", "sms", "phone", "mobile_otp"},
+}}
+
+func (s *AuthServer) preauth(w http.ResponseWriter, r *http.Request, params url.Values) {
+	if (params.Get("username") == "") == (params.Get("user_id") == "") {
+		writeError(w, invalidParams("username"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user := s.lookup(params)
+	if user == nil || user.Outcome == EnrollRequired {
+		writeOK(w, map[string]interface{}{
+			"result":            "enroll",
+			"status_msg":        "Enroll an authentication device to proceed",
+			"enroll_portal_url": fmt.Sprintf("https://%s/portal?code=%s", s.creds.host(), s.newID("")),
+		})
+		return
+	}
+	writeOK(w, map[string]interface{}{
+		"result":     "auth",
+		"status_msg": "Account is active",
+		"devices":    defaultDevices,
+	})
+}
+
+// result returns the auth response for an authentication by user which
+// has finished.
+func result(user *User) map[string]interface{} {
+	switch user.Outcome {
+	case Allow, WaitThenAllow:
+		return map[string]interface{}{
+			"result":     "allow",
+			"status":     "allow",
+			"status_msg": "Success. Logging you in...",
+		}
+	case Timeout:
+		return map[string]interface{}{
+			"result":     "deny",
+			"status":     "timeout",
+			"status_msg": "Login timed out.",
+		}
+	case EnrollRequired:
+		return map[string]interface{}{
+			"result":     "deny",
+			"status":     "deny",
+			"status_msg": "Enroll an authentication device to proceed",
+		}
+	}
+	return map[string]interface{}{
+		"result":     "deny",
+		"status":     "deny",
+		"status_msg": "Login request denied.",
+	}
+}
+
+func (s *AuthServer) auth(w http.ResponseWriter, r *http.Request, params url.Values) {
+	switch params.Get("factor") {
+	case "auto", "push", "passcode", "sms", "phone":
+	default:
+		writeError(w, invalidParams("factor"))
+		return
+	}
+	if (params.Get("username") == "") == (params.Get("user_id") == "") {
+		writeError(w, invalidParams("username"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user := s.lookup(params)
+	if user == nil {
+		writeError(w, invalidParams("username"))
+		return
+	}
+
+	if params.Get("async") != "1" {
+		writeOK(w, result(user))
+		return
+	}
+	txid := s.newID("TX")
+	s.txns[txid] = &transaction{user: *user}
+	writeOK(w, map[string]interface{}{"txid": txid})
+}
+
+func (s *AuthServer) authStatus(w http.ResponseWriter, r *http.Request, params url.Values) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	txn, ok := s.txns[params.Get("txid")]
+	if !ok {
+		writeError(w, invalidParams("txid"))
+		return
+	}
+
+	txn.polls++
+	waitPolls := txn.user.WaitPolls
+	if waitPolls < 1 {
+		waitPolls = 1
+	}
+	if txn.user.Outcome == WaitThenAllow && txn.polls <= waitPolls {
+		writeOK(w, map[string]interface{}{
+			"result":     "waiting",
+			"status":     "pushed",
+			"status_msg": "Pushed a login request to your device...",
+		})
+		return
+	}
+	writeOK(w, result(&txn.user))
+}
+
+// defaultLogo returns a 1x1 PNG.
+func defaultLogo() []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1)))
+	return buf.Bytes()
+}
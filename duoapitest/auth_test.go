@@ -0,0 +1,167 @@
+package duoapitest_test
+
+import (
+	"testing"
+
+	duoapi "github.com/duosecurity/duo_api_golang"
+	"github.com/duosecurity/duo_api_golang/authapi"
+	"github.com/duosecurity/duo_api_golang/duoapitest"
+)
+
+var testCreds = duoapitest.Credentials{IKey: "DIWJ8X6AEYOR5OMC6TQ1", SKey: "Zh5eGmUq9zpfQnyUIu5OL9iWoMMv5ZNmk3zLJ4Ep"}
+
+func newAuthApi(server *duoapitest.AuthServer, skey string) *authapi.AuthApi {
+	return authapi.NewAuthApi(*duoapi.NewDuoApi(testCreds.IKey, skey, duoapitest.DefaultHost, "GoTestClient",
+		duoapi.SetBaseURL(server.BaseURL())))
+}
+
+func TestAuthServerPingAndCheck(t *testing.T) {
+	server := duoapitest.NewAuthServer(testCreds)
+	defer server.Close()
+	api := newAuthApi(server, testCreds.SKey)
+
+	ping, err := api.Ping()
+	if err != nil || ping.Response.Time == 0 {
+		t.Fatalf("Unexpected ping result %+v, %v", ping, err)
+	}
+	check, err := api.Check()
+	if err != nil || check.Response.Time == 0 {
+		t.Fatalf("Unexpected check result %+v, %v", check, err)
+	}
+}
+
+func TestAuthServerRejectsBadSignature(t *testing.T) {
+	server := duoapitest.NewAuthServer(testCreds)
+	defer server.Close()
+	api := newAuthApi(server, "not-the-secret-key")
+
+	_, err := api.Check()
+	if !duoapi.IsUnauthorized(err) {
+		t.Fatalf("Expected an unauthorized error, but got %v", err)
+	}
+	if _, err := api.Ping(); err != nil {
+		t.Fatalf("Unsigned ping failed: %v", err)
+	}
+}
+
+func TestAuthServerLogo(t *testing.T) {
+	server := duoapitest.NewAuthServer(testCreds)
+	defer server.Close()
+	api := newAuthApi(server, testCreds.SKey)
+
+	if _, err := api.Logo(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	server.SetLogo(nil)
+	if _, err := api.Logo(); !duoapi.IsNotFound(err) {
+		t.Fatalf("Expected a not found error, but got %v", err)
+	}
+}
+
+func TestAuthServerOutcomes(t *testing.T) {
+	server := duoapitest.NewAuthServer(testCreds)
+	defer server.Close()
+	api := newAuthApi(server, testCreds.SKey)
+
+	tests := []struct {
+		outcome       duoapitest.Outcome
+		preauthResult string
+		result        string
+		status        string
+	}{
+		{duoapitest.Allow, "auth", "allow", "allow"},
+		{duoapitest.Deny, "auth", "deny", "deny"},
+		{duoapitest.Timeout, "auth", "deny", "timeout"},
+		{duoapitest.EnrollRequired, "enroll", "deny", "deny"},
+	}
+	for _, test := range tests {
+		user := server.AddUser(duoapitest.User{Username: "jsmith", Outcome: test.outcome})
+
+		preauth, err := api.Preauth(authapi.PreauthUsername("jsmith"))
+		if err != nil {
+			t.Fatalf("Unexpected preauth error: %v", err)
+		}
+		if preauth.Response.Result != test.preauthResult {
+			t.Errorf("Outcome %d: expected preauth result %s, but got %s",
+				test.outcome, test.preauthResult, preauth.Response.Result)
+		}
+
+		auth, err := api.Auth("push", authapi.AuthUserId(user.UserID), authapi.AuthDevice("auto"))
+		if err != nil {
+			t.Fatalf("Unexpected auth error: %v", err)
+		}
+		if auth.Response.Result != test.result || auth.Response.Status != test.status {
+			t.Errorf("Outcome %d: expected %s/%s, but got %s/%s", test.outcome,
+				test.result, test.status, auth.Response.Result, auth.Response.Status)
+		}
+	}
+
+	preauth, err := api.Preauth(authapi.PreauthUsername("unknown"))
+	if err != nil || preauth.Response.Result != "enroll" || preauth.Response.Enroll_Portal_Url == "" {
+		t.Fatalf("Expected an unknown user to enroll, but got %+v, %v", preauth, err)
+	}
+	if _, err := api.Auth("push", authapi.AuthUsername("unknown")); !duoapi.IsInvalidParams(err) {
+		t.Fatalf("Expected an invalid params error, but got %v", err)
+	}
+}
+
+func TestAuthServerAsyncWaitThenAllow(t *testing.T) {
+	server := duoapitest.NewAuthServer(testCreds)
+	defer server.Close()
+	api := newAuthApi(server, testCreds.SKey)
+	server.AddUser(duoapitest.User{Username: "jsmith", Outcome: duoapitest.WaitThenAllow, WaitPolls: 2})
+
+	auth, err := api.Auth("push", authapi.AuthUsername("jsmith"), authapi.AuthAsync())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if auth.Response.Txid == "" {
+		t.Fatal("Expected a txid")
+	}
+
+	for _, expected := range []string{"waiting", "waiting", "allow"} {
+		status, err := api.AuthStatus(auth.Response.Txid)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if status.Response.Result != expected {
+			t.Fatalf("Expected %s, but got %s", expected, status.Response.Result)
+		}
+	}
+}
+
+func TestAuthServerEnroll(t *testing.T) {
+	server := duoapitest.NewAuthServer(testCreds)
+	defer server.Close()
+	api := newAuthApi(server, testCreds.SKey)
+
+	enroll, err := api.Enroll(authapi.EnrollUsername("newuser"), authapi.EnrollValidSeconds(60))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if enroll.Response.Username != "newuser" || enroll.Response.User_Id == "" || enroll.Response.Activation_Code == "" {
+		t.Fatalf("Unexpected enroll result %+v", enroll.Response)
+	}
+
+	status, err := api.EnrollStatus(enroll.Response.User_Id, enroll.Response.Activation_Code)
+	if err != nil || status.Response != "waiting" {
+		t.Fatalf("Expected waiting, but got %+v, %v", status, err)
+	}
+	if !server.CompleteEnrollment(enroll.Response.User_Id) {
+		t.Fatal("No pending enrollment to complete")
+	}
+	status, err = api.EnrollStatus(enroll.Response.User_Id, enroll.Response.Activation_Code)
+	if err != nil || status.Response != "success" {
+		t.Fatalf("Expected success, but got %+v, %v", status, err)
+	}
+	status, err = api.EnrollStatus(enroll.Response.User_Id, "duo://wrong")
+	if err != nil || status.Response != "invalid" {
+		t.Fatalf("Expected invalid, but got %+v, %v", status, err)
+	}
+
+	preauth, err := api.Preauth(authapi.PreauthUsername("newuser"))
+	if err != nil || preauth.Response.Result != "auth" {
+		t.Fatalf("Expected the enrolled user to authenticate, but got %+v, %v", preauth, err)
+	}
+}
@@ -0,0 +1,106 @@
+// Command fakeduoadmin serves duoapitest's in-memory Admin API stand-in, so
+// that programs built on the admin package can be exercised end to end
+// without a Duo account.
+//
+// Usage:
+//
+//	fakeduoadmin -ikey DIXXXXXXXXXXXXXXXXXX -skey secret [-addr 127.0.0.1:8080] [-fixtures objects.json]
+//
+// Point clients at it with duoapi.SetBaseURL, and sign requests for the API
+// host given by -host:
+//
+//	baseURL, err := url.Parse("http://127.0.0.1:8080")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	client := admin.FromDuoApi(duoapi.NewDuoApi(ikey, skey, duoapitest.DefaultHost, "",
+//		duoapi.SetBaseURL(baseURL)))
+//
+// Only the endpoints listed in the documentation of duoapitest.AdminAPI
+// are served; others are answered with HTTP 404.
+//
+// The optional fixtures file seeds the server with objects, in the Admin
+// API's JSON representation:
+//
+//	{
+//	  "groups": [{"group_id": "DG000000000000000001", "name": "Engineering"}],
+//	  "users": [{"username": "jsmith", "groups": [{"group_id": "DG000000000000000001"}]}],
+//	  "phones": [{"number": "+15555550100", "users": [{"user_id": "..."}]}],
+//	  "tokens": [], "integrations": [], "admins": []
+//	}
+//
+// Groups are added first, then users, phones, tokens, integrations and
+// administrators, so later objects may refer to earlier ones by ID.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/duosecurity/duo_api_golang/admin"
+	"github.com/duosecurity/duo_api_golang/duoapitest"
+)
+
+type fixtures struct {
+	Groups         []admin.Group         `json:"groups"`
+	Users          []admin.User          `json:"users"`
+	Phones         []admin.Phone         `json:"phones"`
+	Tokens         []admin.Token         `json:"tokens"`
+	Integrations   []admin.Integration   `json:"integrations"`
+	Administrators []admin.Administrator `json:"admins"`
+}
+
+func load(api *duoapitest.AdminAPI, path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var f fixtures
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	for _, group := range f.Groups {
+		api.AddGroup(group)
+	}
+	for _, user := range f.Users {
+		api.AddUser(user)
+	}
+	for _, phone := range f.Phones {
+		api.AddPhone(phone)
+	}
+	for _, token := range f.Tokens {
+		api.AddToken(token)
+	}
+	for _, integration := range f.Integrations {
+		api.AddIntegration(integration)
+	}
+	for _, administrator := range f.Administrators {
+		api.AddAdministrator(administrator)
+	}
+	return nil
+}
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8080", "address to listen on")
+	ikey := flag.String("ikey", "", "integration key requests must be signed with")
+	skey := flag.String("skey", "", "secret key requests must be signed with")
+	host := flag.String("host", duoapitest.DefaultHost, "API hostname requests are signed for")
+	fixturesPath := flag.String("fixtures", "", "JSON file of objects to seed the server with")
+	flag.Parse()
+
+	if *ikey == "" || *skey == "" {
+		log.Fatal("fakeduoadmin: -ikey and -skey are required")
+	}
+	api := duoapitest.NewAdminAPI(duoapitest.Credentials{IKey: *ikey, SKey: *skey, Host: *host})
+	if *fixturesPath != "" {
+		if err := load(api, *fixturesPath); err != nil {
+			log.Fatalf("fakeduoadmin: loading %s: %v", *fixturesPath, err)
+		}
+	}
+
+	log.Printf("fakeduoadmin: serving the Admin API on http://%s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, api))
+}
@@ -0,0 +1,124 @@
+// Package duoapitest provides in-process stand-ins for Duo's APIs, for
+// testing code built on the duoapi, authapi, admin and universal packages
+// without talking to Duo.  The command duoapitest/cmd/fakeduoadmin serves
+// the Admin API stand-in on its own.
+//
+// Servers are started with httptest, verify each request's signature
+// against the integration key and secret key they were created with, and
+// return the same JSON a Duo server would.  The Universal Prompt stand-in
+// verifies the client's JWTs with the client ID and secret instead.
+package duoapitest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	duoapi "github.com/duosecurity/duo_api_golang"
+)
+
+// DefaultHost is the API hostname requests must be signed for, unless a
+// server is given another.
+const DefaultHost = "api-test.duosecurity.com"
+
+// Credentials are the keys a server accepts signatures from.
+type Credentials struct {
+	IKey string
+	SKey string
+	// Host is the API hostname requests are signed for.  It defaults to
+	// DefaultHost.
+	Host string
+}
+
+func (c Credentials) host() string {
+	if c.Host == "" {
+		return DefaultHost
+	}
+	return c.Host
+}
+
+// apiError is a Duo error response.
+type apiError struct {
+	status  int
+	code    int
+	message string
+	detail  string
+}
+
+var (
+	errMissingCredentials = apiError{http.StatusUnauthorized, 40101, "Missing request credentials", ""}
+	errInvalidIKey        = apiError{http.StatusUnauthorized, 40102, "Invalid integration key in request credentials", ""}
+	errInvalidSignature   = apiError{http.StatusUnauthorized, 40103, "Invalid signature in request credentials", ""}
+	errNotFound           = apiError{http.StatusNotFound, 40401, "Resource not found", ""}
+	errMethodNotAllowed   = apiError{http.StatusMethodNotAllowed, 40501, "Method not allowed", ""}
+	errDuplicate          = apiError{http.StatusBadRequest, 40003, "Duplicate resource", ""}
+	errRateLimited        = apiError{http.StatusTooManyRequests, 42901, "Too Many Requests", ""}
+)
+
+func invalidParams(detail string) apiError {
+	return apiError{http.StatusBadRequest, 40002, "Invalid request parameters", detail}
+}
+
+func writeError(w http.ResponseWriter, e apiError) {
+	body := map[string]interface{}{
+		"stat":    "FAIL",
+		"code":    e.code,
+		"message": e.message,
+	}
+	if e.detail != "" {
+		body["message_detail"] = e.detail
+	}
+	writeJSONStatus(w, e.status, body)
+}
+
+func writeJSONStatus(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeOK writes a successful response wrapping response.
+func writeOK(w http.ResponseWriter, response interface{}) {
+	writeJSONStatus(w, http.StatusOK, map[string]interface{}{
+		"stat":     "OK",
+		"response": response,
+	})
+}
+
+// requestParams returns the parameters of r, from its form body for POST
+// and PUT requests and from its query otherwise.
+func requestParams(r *http.Request) (url.Values, error) {
+	if r.Method == http.MethodPost || r.Method == http.MethodPut {
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+		return r.PostForm, nil
+	}
+	return r.URL.Query(), nil
+}
+
+// verify checks the signature of r, returning the error to respond with if
+// it is not valid.
+func (c Credentials) verify(r *http.Request) *apiError {
+	verifier := duoapi.Verifier{
+		Lookup: func(ctx context.Context, ikey string) (string, error) {
+			if ikey != c.IKey {
+				return "", duoapi.ErrUnknownIKey
+			}
+			return c.SKey, nil
+		},
+		Host: c.host(),
+	}
+	_, err := verifier.Verify(r)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, duoapi.ErrMissingSignature):
+		return &errMissingCredentials
+	case errors.Is(err, duoapi.ErrUnknownIKey):
+		return &errInvalidIKey
+	}
+	return &errInvalidSignature
+}
@@ -0,0 +1,280 @@
+package duoapitest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/duosecurity/duo_api_golang/internal/jwt"
+)
+
+// UniversalServer is an in-process stand-in for Duo's Universal Prompt,
+// serving /oauth/v1/health_check, authorize and token for one Web SDK
+// application, whose client ID and secret are its Credentials' IKey and
+// SKey.  Instead of prompting the user, authorize redirects straight back
+// to the application with a code, and the id_token for the code reports
+// the user's Outcome.
+type UniversalServer struct {
+	*httptest.Server
+	creds Credentials
+
+	mu        sync.Mutex
+	unhealthy bool
+	outcomes  map[string]Outcome
+	codes     map[string]*universalAuth
+	nextID    int
+}
+
+// universalAuth is an authorization awaiting exchange of its code.
+type universalAuth struct {
+	username    string
+	nonce       string
+	redirectURI string
+	expiration  time.Time
+}
+
+// codeLifetime is how long an authorization code may be exchanged for.
+const codeLifetime = time.Minute
+
+// NewUniversalServer starts a UniversalServer for the application with the
+// client ID and secret in creds.  Close it when done.
+//
+// Example:
+//
+//	server := duoapitest.NewUniversalServer(duoapitest.Credentials{IKey: clientID, SKey: clientSecret})
+//	defer server.Close()
+//	client, err := universal.NewClient(clientID, clientSecret, duoapitest.DefaultHost, redirectURI,
+//		duoapi.SetBaseURL(server.BaseURL()))
+func NewUniversalServer(creds Credentials) *UniversalServer {
+	s := &UniversalServer{
+		creds:    creds,
+		outcomes: make(map[string]Outcome),
+		codes:    make(map[string]*universalAuth),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/v1/health_check", s.healthCheck)
+	mux.HandleFunc("/oauth/v1/authorize", s.authorize)
+	mux.HandleFunc("/oauth/v1/token", s.token)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, errNotFound)
+	})
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// BaseURL returns the server's URL, for duoapi.SetBaseURL.
+func (s *UniversalServer) BaseURL() *url.URL {
+	u, _ := url.Parse(s.URL)
+	return u
+}
+
+// SetHealthy sets whether health checks succeed, as they do by default.
+func (s *UniversalServer) SetHealthy(healthy bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unhealthy = !healthy
+}
+
+// SetOutcome sets how username's authentications end; by default they are
+// allowed.
+func (s *UniversalServer) SetOutcome(username string, outcome Outcome) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outcomes[username] = outcome
+}
+
+// endpoint returns the URL of uri on the server's API host, to which JWTs
+// are addressed.
+func (s *UniversalServer) endpoint(uri string) string {
+	return "https://" + s.creds.host() + uri
+}
+
+// clientClaims are the claims of the JWTs a client signs.
+type clientClaims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	Audience  string `json:"aud"`
+	ExpiresAt int64  `json:"exp"`
+
+	ClientID     string `json:"client_id"`
+	RedirectURI  string `json:"redirect_uri"`
+	ResponseType string `json:"response_type"`
+	Scope        string `json:"scope"`
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	Username     string `json:"duo_uname"`
+}
+
+// verifyClientJWT returns the claims of token if the client signed it for
+// the endpoint at uri, and it has not expired.
+func (s *UniversalServer) verifyClientJWT(token string, uri string) (*clientClaims, string) {
+	claims := &clientClaims{}
+	if err := jwt.Verify(token, s.creds.SKey, claims); err != nil {
+		return nil, err.Error()
+	}
+	switch {
+	case claims.Issuer != s.creds.IKey:
+		return nil, "unexpected issuer"
+	case claims.Audience != s.endpoint(uri):
+		return nil, "unexpected audience"
+	case time.Now().After(time.Unix(claims.ExpiresAt, 0)):
+		return nil, "expired"
+	}
+	return claims, ""
+}
+
+func (s *UniversalServer) healthCheck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, errMethodNotAllowed)
+		return
+	}
+	if r.PostFormValue("client_id") != s.creds.IKey {
+		writeError(w, apiError{http.StatusBadRequest, 40002, "invalid_client", "The provided client_id is invalid."})
+		return
+	}
+	claims, problem := s.verifyClientJWT(r.PostFormValue("client_assertion"), "/oauth/v1/health_check")
+	if claims == nil || claims.Subject != s.creds.IKey {
+		writeError(w, apiError{http.StatusBadRequest, 40002, "invalid_client", "Invalid client assertion: " + problem})
+		return
+	}
+
+	s.mu.Lock()
+	unhealthy := s.unhealthy
+	s.mu.Unlock()
+	if unhealthy {
+		writeError(w, apiError{http.StatusServiceUnavailable, 50301, "Service unavailable", ""})
+		return
+	}
+	writeOK(w, map[string]interface{}{"timestamp": time.Now().Unix()})
+}
+
+func (s *UniversalServer) authorize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, errMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	if query.Get("response_type") != "code" || query.Get("client_id") != s.creds.IKey {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	claims, problem := s.verifyClientJWT(query.Get("request"), "")
+	if claims == nil {
+		http.Error(w, "invalid request object: "+problem, http.StatusBadRequest)
+		return
+	}
+	if claims.ClientID != s.creds.IKey || claims.ResponseType != "code" || claims.Scope != "openid" ||
+		claims.Username == "" || claims.State == "" || claims.RedirectURI == "" {
+		http.Error(w, "invalid request object claims", http.StatusBadRequest)
+		return
+	}
+	redirect, err := url.Parse(claims.RedirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	code := fmt.Sprintf("%032d", s.nextID)
+	s.codes[code] = &universalAuth{
+		username:    claims.Username,
+		nonce:       claims.Nonce,
+		redirectURI: claims.RedirectURI,
+		expiration:  time.Now().Add(codeLifetime),
+	}
+	s.mu.Unlock()
+
+	params := redirect.Query()
+	params.Set("duo_code", code)
+	params.Set("state", claims.State)
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// writeTokenError writes an OAuth error response.
+func writeTokenError(w http.ResponseWriter, code string, description string) {
+	writeJSONStatus(w, http.StatusBadRequest, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+func (s *UniversalServer) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, errMethodNotAllowed)
+		return
+	}
+	if r.PostFormValue("grant_type") != "authorization_code" {
+		writeTokenError(w, "unsupported_grant_type", "The grant type must be authorization_code.")
+		return
+	}
+	if r.PostFormValue("client_assertion_type") != "urn:ietf:params:oauth:client-assertion-type:jwt-bearer" {
+		writeTokenError(w, "invalid_client", "Unsupported client assertion type.")
+		return
+	}
+	claims, problem := s.verifyClientJWT(r.PostFormValue("client_assertion"), "/oauth/v1/token")
+	if claims == nil || claims.Subject != s.creds.IKey {
+		writeTokenError(w, "invalid_client", "Invalid client assertion: "+problem)
+		return
+	}
+
+	s.mu.Lock()
+	code := r.PostFormValue("code")
+	auth := s.codes[code]
+	delete(s.codes, code)
+	var outcome Outcome
+	if auth != nil {
+		outcome = s.outcomes[auth.username]
+	}
+	s.mu.Unlock()
+	if auth == nil || time.Now().After(auth.expiration) {
+		writeTokenError(w, "invalid_grant", "The authorization code is invalid or expired.")
+		return
+	}
+	if r.PostFormValue("redirect_uri") != auth.redirectURI {
+		writeTokenError(w, "invalid_grant", "The redirect_uri does not match the authorization request.")
+		return
+	}
+
+	now := time.Now()
+	result := map[string]string{"result": "allow", "status": "allow", "status_msg": "Login Successful"}
+	if outcome != Allow && outcome != WaitThenAllow {
+		result = map[string]string{"result": "deny", "status": "deny", "status_msg": "Login denied"}
+	}
+	idClaims := map[string]interface{}{
+		"iss":                s.endpoint("/oauth/v1/token"),
+		"sub":                auth.username,
+		"aud":                s.creds.IKey,
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"iat":                now.Unix(),
+		"auth_time":          now.Unix(),
+		"preferred_username": auth.username,
+		"auth_result":        result,
+		"auth_context": map[string]interface{}{
+			"event_type": "authentication",
+			"factor":     "duo_push",
+			"result":     result["result"],
+			"timestamp":  now.Unix(),
+			"user":       map[string]interface{}{"name": auth.username},
+		},
+	}
+	if auth.nonce != "" {
+		idClaims["nonce"] = auth.nonce
+	}
+	idToken, err := jwt.Sign(idClaims, s.creds.SKey)
+	if err != nil {
+		writeTokenError(w, "server_error", err.Error())
+		return
+	}
+	writeJSONStatus(w, http.StatusOK, map[string]interface{}{
+		"id_token":     idToken,
+		"access_token": fmt.Sprintf("%032d", now.UnixNano()),
+		"expires_in":   3600,
+		"token_type":   "Bearer",
+	})
+}
@@ -0,0 +1,152 @@
+// Package duoweb implements the signing for the legacy Duo Web SDK v2, the
+// iframe-based flow in which the application signs a request for Duo's
+// prompt and verifies the response the prompt posts back.  New
+// applications should use the Universal Prompt, in package universal.
+//
+// A login runs in two steps: SignRequest returns the sig_request to hand to
+// the Duo iframe, and VerifyResponse checks the sig_response it posts back
+// and returns the authenticated username.
+// See https://duo.com/docs/duoweb-v2
+package duoweb
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/duosecurity/duo_api_golang/internal/hmacsig"
+)
+
+const (
+	duoPrefix  = "TX"
+	appPrefix  = "APP"
+	authPrefix = "AUTH"
+
+	// duoExpire is how long the TX value Duo checks is valid for.
+	duoExpire = 300 * time.Second
+	// appExpire is how long the APP value the application checks is valid
+	// for.
+	appExpire = 3600 * time.Second
+
+	ikeyLength = 20
+	skeyLength = 40
+	// MinAKeyLength is the shortest application secret key accepted.
+	MinAKeyLength = 40
+)
+
+var (
+	// ErrInvalidUsername, ErrInvalidIKey, ErrInvalidSKey and ErrInvalidAKey
+	// are returned by SignRequest for invalid arguments.
+	ErrInvalidUsername = errors.New("duoweb: the username is invalid")
+	ErrInvalidIKey     = errors.New("duoweb: the Duo integration key is invalid")
+	ErrInvalidSKey     = errors.New("duoweb: the Duo secret key is invalid")
+	ErrInvalidAKey     = fmt.Errorf("duoweb: the application secret key must be at least %d characters", MinAKeyLength)
+	// ErrInvalidResponse is returned, wrapped with the reason, by
+	// VerifyResponse for a response which fails verification.
+	ErrInvalidResponse = errors.New("duoweb: invalid response")
+)
+
+// SignRequest returns the sig_request for username's login, signed for Duo
+// with the integration's ikey and skey and for the application with akey.
+// Duo accepts it for five minutes.
+//
+// Example: sigRequest, err := duoweb.SignRequest(ikey, skey, akey, "alice")
+func SignRequest(ikey string, skey string, akey string, username string) (string, error) {
+	return signRequest(ikey, skey, akey, username, time.Now())
+}
+
+func signRequest(ikey string, skey string, akey string, username string, now time.Time) (string, error) {
+	switch {
+	case username == "" || strings.Contains(username, "|"):
+		return "", ErrInvalidUsername
+	case len(ikey) != ikeyLength:
+		return "", ErrInvalidIKey
+	case len(skey) != skeyLength:
+		return "", ErrInvalidSKey
+	case len(akey) < MinAKeyLength:
+		return "", ErrInvalidAKey
+	}
+	duoSig := signValue(skey, duoPrefix, username, ikey, now.Add(duoExpire))
+	appSig := signValue(akey, appPrefix, username, ikey, now.Add(appExpire))
+	return duoSig + ":" + appSig, nil
+}
+
+// VerifyResponse verifies the sig_response Duo's prompt posted back for a
+// request from SignRequest with the same keys, and returns the username
+// who authenticated.  Both the AUTH value Duo signed with skey and the APP
+// value from the request must be intact, unexpired, for ikey and for the
+// same user.
+func VerifyResponse(ikey string, skey string, akey string, sigResponse string) (string, error) {
+	return verifyResponse(ikey, skey, akey, sigResponse, time.Now())
+}
+
+func verifyResponse(ikey string, skey string, akey string, sigResponse string, now time.Time) (string, error) {
+	sigs := strings.Split(sigResponse, ":")
+	if len(sigs) != 2 {
+		return "", fmt.Errorf("%w: malformed", ErrInvalidResponse)
+	}
+	authUser, err := parseValue(skey, sigs[0], authPrefix, ikey, now)
+	if err != nil {
+		return "", err
+	}
+	appUser, err := parseValue(akey, sigs[1], appPrefix, ikey, now)
+	if err != nil {
+		return "", err
+	}
+	if authUser != appUser {
+		return "", fmt.Errorf("%w: the AUTH and APP values are for different users", ErrInvalidResponse)
+	}
+	return authUser, nil
+}
+
+// signValue returns the value prefix|base64(username|ikey|expiration)
+// followed by its HMAC-SHA1 signature with key.
+func signValue(key string, prefix string, username string, ikey string, expiration time.Time) string {
+	vals := username + "|" + ikey + "|" + strconv.FormatInt(expiration.Unix(), 10)
+	cookie := prefix + "|" + base64.StdEncoding.EncodeToString([]byte(vals))
+	return cookie + "|" + hmacsig.Hex(sha1.New, key, cookie)
+}
+
+// parseValue returns the username in value if it is signed with key, has
+// prefix, is for ikey and has not expired.
+func parseValue(key string, value string, prefix string, ikey string, now time.Time) (string, error) {
+	parts := strings.Split(value, "|")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("%w: malformed %s value", ErrInvalidResponse, prefix)
+	}
+	valuePrefix, encoded, sig := parts[0], parts[1], parts[2]
+
+	expected := hmacsig.Hex(sha1.New, key, valuePrefix+"|"+encoded)
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return "", fmt.Errorf("%w: invalid %s signature", ErrInvalidResponse, prefix)
+	}
+	if valuePrefix != prefix {
+		return "", fmt.Errorf("%w: unexpected prefix %q", ErrInvalidResponse, valuePrefix)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed %s value", ErrInvalidResponse, prefix)
+	}
+	vals := strings.Split(string(decoded), "|")
+	if len(vals) != 3 {
+		return "", fmt.Errorf("%w: malformed %s value", ErrInvalidResponse, prefix)
+	}
+	username, valueIKey, expires := vals[0], vals[1], vals[2]
+	expiration, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed %s expiration", ErrInvalidResponse, prefix)
+	}
+	if now.Unix() >= expiration {
+		return "", fmt.Errorf("%w: %s value expired at %v", ErrInvalidResponse, prefix, time.Unix(expiration, 0))
+	}
+	if valueIKey != ikey {
+		return "", fmt.Errorf("%w: %s value is for another integration", ErrInvalidResponse, prefix)
+	}
+	return username, nil
+}
@@ -0,0 +1,140 @@
+package duoweb
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The keys and responses are the test vectors of Duo's own Web SDK v2
+// libraries.
+const (
+	testIKey      = "DIXXXXXXXXXXXXXXXXXX"
+	testWrongIKey = "DIXXXXXXXXXXXXXXXXXY"
+	testSKey      = "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef"
+	testAKey      = "useacustomerprovidedapplicationsecretkey"
+	testUser      = "testuser"
+
+	invalidResponse     = "AUTH|INVALID|SIG"
+	expiredResponse     = "AUTH|dGVzdHVzZXJ8RElYWFhYWFhYWFhYWFhYWFhYWFh8MTMwMDE1Nzg3NA==|cb8f4d60ec7c261394cd5ee5a17e46ca7440d702"
+	futureResponse      = "AUTH|dGVzdHVzZXJ8RElYWFhYWFhYWFhYWFhYWFhYWFh8MTYxNTcyNzI0Mw==|d20ad0d1e62d84b00a3e74ec201a5917e77b6aef"
+	wrongParamsResponse = "AUTH|dGVzdHVzZXJ8RElYWFhYWFhYWFhYWFhYWFhYWFh8MTYxNTcyNzI0M3xpbnZhbGlkZXh0cmFkYXRh|6cdbec0fbfa0d3f335c76b0786a4a18eac6cdca7"
+	wrongParamsApp      = "APP|dGVzdHVzZXJ8RElYWFhYWFhYWFhYWFhYWFhYWFh8MTYxNTcyNzI0M3xpbnZhbGlkZXh0cmFkYXRh|7c2065ea122d028b03ef0295a4b4c5521823b9b5"
+)
+
+// testNow is before futureResponse expires at 1615727243, and late enough
+// that an APP value signed then has not expired by then either.
+var testNow = time.Unix(1615700000, 0)
+
+func TestSignRequest(t *testing.T) {
+	sigRequest, err := signRequest(testIKey, testSKey, testAKey, testUser, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Computed independently for testNow, with Python's hmac and base64.
+	expected := "TX|dGVzdHVzZXJ8RElYWFhYWFhYWFhYWFhYWFhYWFh8MTYxNTcwMDMwMA==|89e2cac153715d726f0019c101d8d1b1884cf201" +
+		":APP|dGVzdHVzZXJ8RElYWFhYWFhYWFhYWFhYWFhYWFh8MTYxNTcwMzYwMA==|b225b3707f6dd993e7e6e86ca7170be472bbe021"
+	if sigRequest != expected {
+		t.Errorf("Expected %q, but got %q", expected, sigRequest)
+	}
+}
+
+func TestSignRequestInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		ikey     string
+		skey     string
+		akey     string
+		username string
+		expected error
+	}{
+		{"empty username", testIKey, testSKey, testAKey, "", ErrInvalidUsername},
+		{"username with a separator", testIKey, testSKey, testAKey, "in|valid", ErrInvalidUsername},
+		{"short ikey", "invalid", testSKey, testAKey, testUser, ErrInvalidIKey},
+		{"short skey", testIKey, "invalid", testAKey, testUser, ErrInvalidSKey},
+		{"short akey", testIKey, testSKey, "invalid", testUser, ErrInvalidAKey},
+	}
+	for _, test := range tests {
+		sigRequest, err := SignRequest(test.ikey, test.skey, test.akey, test.username)
+		if err != test.expected {
+			t.Errorf("%s: Expected %v, but got %q, %v", test.name, test.expected, sigRequest, err)
+		}
+	}
+}
+
+func TestVerifyResponse(t *testing.T) {
+	sigRequest, err := signRequest(testIKey, testSKey, testAKey, testUser, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validApp := strings.Split(sigRequest, ":")[1]
+	sigRequest, err = signRequest(testIKey, testSKey, "invalidinvalidinvalidinvalidinvalidinvalid", testUser, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalidApp := strings.Split(sigRequest, ":")[1]
+
+	username, err := verifyResponse(testIKey, testSKey, testAKey, futureResponse+":"+validApp, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if username != testUser {
+		t.Errorf("Expected %q, but got %q", testUser, username)
+	}
+
+	tests := []struct {
+		name        string
+		ikey        string
+		sigResponse string
+	}{
+		{"invalid response", testIKey, invalidResponse + ":" + validApp},
+		{"expired response", testIKey, expiredResponse + ":" + validApp},
+		{"invalid app value", testIKey, futureResponse + ":" + invalidApp},
+		{"wrong ikey", testWrongIKey, futureResponse + ":" + validApp},
+		{"extra response params", testIKey, wrongParamsResponse + ":" + validApp},
+		{"extra app params", testIKey, futureResponse + ":" + wrongParamsApp},
+		{"missing app value", testIKey, futureResponse},
+		{"swapped values", testIKey, validApp + ":" + futureResponse},
+	}
+	for _, test := range tests {
+		username, err := verifyResponse(test.ikey, testSKey, testAKey, test.sigResponse, testNow)
+		if !errors.Is(err, ErrInvalidResponse) {
+			t.Errorf("%s: Expected ErrInvalidResponse, but got %q, %v", test.name, username, err)
+		}
+	}
+}
+
+func TestVerifyResponseExpiredApp(t *testing.T) {
+	sigRequest, err := signRequest(testIKey, testSKey, testAKey, testUser, testNow.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	validApp := strings.Split(sigRequest, ":")[1]
+	_, err = verifyResponse(testIKey, testSKey, testAKey, futureResponse+":"+validApp, testNow)
+	if !errors.Is(err, ErrInvalidResponse) || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("Expected an expired APP value, but got %v", err)
+	}
+}
+
+func TestVerifyResponseDifferentUsers(t *testing.T) {
+	sigRequest, err := SignRequest(testIKey, testSKey, testAKey, "otheruser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := strings.Split(sigRequest, ":")[1]
+	auth := signValue(testSKey, "AUTH", testUser, testIKey, time.Now().Add(time.Minute))
+	_, err = VerifyResponse(testIKey, testSKey, testAKey, auth+":"+app)
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("Expected ErrInvalidResponse, but got %v", err)
+	}
+
+	auth = signValue(testSKey, "AUTH", "otheruser", testIKey, time.Now().Add(time.Minute))
+	username, err := VerifyResponse(testIKey, testSKey, testAKey, auth+":"+app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if username != "otheruser" {
+		t.Errorf("Expected otheruser, but got %q", username)
+	}
+}
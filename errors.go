@@ -0,0 +1,133 @@
+package duoapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error describes a failed Duo API call: either a non-2xx HTTP response, or
+// a response whose stat is "FAIL".
+type Error struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Code is Duo's five digit error code, or 0 if the response had none.
+	Code int32
+	// Message and MessageDetail are Duo's description of the error.
+	Message       string
+	MessageDetail string
+	// Path is the URI of the failed call.
+	Path string
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("duoapi: %s failed with HTTP %d", e.Path, e.StatusCode)
+	if e.Code != 0 {
+		msg += fmt.Sprintf(", code %d", e.Code)
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.MessageDetail != "" {
+		msg += " (" + e.MessageDetail + ")"
+	}
+	return msg
+}
+
+// Sentinel errors which an *Error matches with errors.Is, based on its HTTP
+// status or, failing that, its Duo error code.
+var (
+	ErrInvalidParams = errors.New("duoapi: invalid request parameters")
+	ErrUnauthorized  = errors.New("duoapi: unauthorized")
+	ErrNotFound      = errors.New("duoapi: resource not found")
+	ErrRateLimited   = errors.New("duoapi: rate limited")
+)
+
+// Is reports whether e matches one of the sentinel errors above.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrInvalidParams:
+		return e.hasStatus(http.StatusBadRequest)
+	case ErrUnauthorized:
+		return e.hasStatus(http.StatusUnauthorized)
+	case ErrNotFound:
+		return e.hasStatus(http.StatusNotFound)
+	case ErrRateLimited:
+		return e.hasStatus(http.StatusTooManyRequests)
+	}
+	return false
+}
+
+// Duo error codes are the HTTP status followed by two more digits,
+// e.g. 40401 for a missing resource.
+func (e *Error) hasStatus(status int) bool {
+	return e.StatusCode == status || int(e.Code)/100 == status
+}
+
+// IsInvalidParams reports whether err is an *Error for a request Duo
+// rejected as malformed (HTTP 400).
+func IsInvalidParams(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Is(ErrInvalidParams)
+}
+
+// IsUnauthorized reports whether err is an *Error for a request that failed
+// authentication (HTTP 401), e.g. a bad integration key or signature.
+func IsUnauthorized(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Is(ErrUnauthorized)
+}
+
+// IsNotFound reports whether err is an *Error for a missing resource
+// (HTTP 404).
+func IsNotFound(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Is(ErrNotFound)
+}
+
+// IsRateLimited reports whether err is an *Error for a rate limited
+// request (HTTP 429), or ErrRateLimitExceeded from a RateLimiter.
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// CheckResponse returns an *Error if resp has a non-2xx status or body is a
+// StatResult whose stat is "FAIL". Otherwise it returns nil.
+func CheckResponse(resp *http.Response, body []byte) error {
+	result := StatResult{}
+	jsonErr := json.Unmarshal(body, &result)
+	success := resp.StatusCode >= 200 && resp.StatusCode < 300
+	if success && (jsonErr != nil || result.Stat != "FAIL") {
+		return nil
+	}
+
+	if jsonErr != nil {
+		return newError(resp, nil)
+	}
+	return newError(resp, &result)
+}
+
+// newError returns the *Error for resp, whose body decoded to result, or
+// could not be decoded if result is nil.
+func newError(resp *http.Response, result *StatResult) *Error {
+	e := &Error{StatusCode: resp.StatusCode}
+	if resp.Request != nil && resp.Request.URL != nil {
+		e.Path = resp.Request.URL.Path
+	}
+	if result != nil {
+		if result.Code != nil {
+			e.Code = *result.Code
+		}
+		if result.Message != nil {
+			e.Message = *result.Message
+		}
+		if result.Message_Detail != nil {
+			e.MessageDetail = *result.Message_Detail
+		}
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
@@ -0,0 +1,22 @@
+// Package hmacsig computes the HMAC signatures with which requests to Duo's
+// APIs, and the values of the legacy Duo Web SDK, are signed.
+package hmacsig
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"hash"
+)
+
+// Sum returns the HMAC of message with key, using the hash h.
+func Sum(h func() hash.Hash, key string, message string) []byte {
+	mac := hmac.New(h, []byte(key))
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+// Hex is like Sum, but returns the HMAC hex encoded, as Duo's signatures
+// are.
+func Hex(h func() hash.Hash, key string, message string) string {
+	return hex.EncodeToString(Sum(h, key, message))
+}
@@ -0,0 +1,83 @@
+// Package jwt signs and verifies the HS512 JSON Web Tokens exchanged with
+// Duo's Universal Prompt.
+package jwt
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrMalformed is returned for a token which is not a JWT.
+	ErrMalformed = errors.New("jwt: malformed token")
+	// ErrAlgorithm is returned for a token not signed with HS512.
+	ErrAlgorithm = errors.New("jwt: unexpected signing algorithm")
+	// ErrSignature is returned for a token whose signature does not match.
+	ErrSignature = errors.New("jwt: invalid signature")
+)
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+var encoding = base64.RawURLEncoding
+
+// Sign returns claims, encoded as JSON, in a JWT signed with secret.
+func Sign(claims interface{}, secret string) (string, error) {
+	head, err := json.Marshal(header{Alg: "HS512", Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := encoding.EncodeToString(head) + "." + encoding.EncodeToString(payload)
+	return signed + "." + encoding.EncodeToString(mac(signed, secret)), nil
+}
+
+// Verify checks that token was signed with secret, and decodes its claims
+// into claims.  It does not check the claims themselves.
+func Verify(token string, secret string, claims interface{}) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrMalformed
+	}
+	var head header
+	if err := decode(parts[0], &head); err != nil {
+		return err
+	}
+	if head.Alg != "HS512" {
+		return ErrAlgorithm
+	}
+	sig, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return ErrMalformed
+	}
+	if !hmac.Equal(sig, mac(parts[0]+"."+parts[1], secret)) {
+		return ErrSignature
+	}
+	return decode(parts[1], claims)
+}
+
+func decode(part string, v interface{}) error {
+	data, err := encoding.DecodeString(part)
+	if err != nil {
+		return ErrMalformed
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrMalformed
+	}
+	return nil
+}
+
+func mac(signed string, secret string) []byte {
+	h := hmac.New(sha512.New, []byte(secret))
+	h.Write([]byte(signed))
+	return h.Sum(nil)
+}
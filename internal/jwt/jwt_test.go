@@ -0,0 +1,53 @@
+package jwt
+
+import (
+	"strings"
+	"testing"
+)
+
+type claims struct {
+	Issuer string `json:"iss"`
+	Expiry int64  `json:"exp"`
+}
+
+func TestSignAndVerify(t *testing.T) {
+	token, err := Sign(claims{Issuer: "DIXXXXXXXXXXXXXXXXXX", Expiry: 1700000000}, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, "eyJhbGciOiJIUzUxMiIsInR5cCI6IkpXVCJ9.") {
+		t.Errorf("Unexpected header in %q", token)
+	}
+
+	var got claims
+	if err := Verify(token, "secret", &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Issuer != "DIXXXXXXXXXXXXXXXXXX" || got.Expiry != 1700000000 {
+		t.Errorf("Unexpected claims %+v", got)
+	}
+
+	if err := Verify(token, "other-secret", &got); err != ErrSignature {
+		t.Errorf("Expected ErrSignature, but got %v", err)
+	}
+	parts := strings.Split(token, ".")
+	tampered := parts[0] + "." + encoding.EncodeToString([]byte(`{"iss":"DIYYYYYYYYYYYYYYYYYY"}`)) + "." + parts[2]
+	if err := Verify(tampered, "secret", &got); err != ErrSignature {
+		t.Errorf("Expected ErrSignature for a tampered token, but got %v", err)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	none := encoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + encoding.EncodeToString([]byte(`{}`)) + "."
+	tests := map[string]error{
+		"not.a.jwt": ErrMalformed,
+		"abc":       ErrMalformed,
+		none:        ErrAlgorithm,
+	}
+	for token, want := range tests {
+		var got claims
+		if err := Verify(token, "secret", &got); err != want {
+			t.Errorf("Verify(%q) = %v, want %v", token, err, want)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package duoapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// LogLevel is the severity of a logged event.
+type LogLevel int
+
+const (
+	// LevelDebug is used for calls which succeeded.
+	LevelDebug LogLevel = iota
+	// LevelInfo is not used by this package, but is available to loggers.
+	LevelInfo
+	// LevelWarn is used for retried attempts and calls which failed with an
+	// HTTP error status.
+	LevelWarn
+	// LevelError is used for calls which failed without a response, and for
+	// responses which could not be decoded.
+	LevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return "UNKNOWN"
+}
+
+// LogField is a key and value attached to a logged event.
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger records the events of Duo API calls.  The fields of each event
+// are among method, path, params, attempt, attempts, status, latency,
+// backoff and error; latency and backoff are time.Durations.  Log must be
+// safe for concurrent use.
+type Logger interface {
+	Log(ctx context.Context, level LogLevel, msg string, fields ...LogField)
+}
+
+// LoggerFunc adapts a function to a Logger.
+type LoggerFunc func(ctx context.Context, level LogLevel, msg string, fields ...LogField)
+
+// Log implements Logger.
+func (f LoggerFunc) Log(ctx context.Context, level LogLevel, msg string, fields ...LogField) {
+	f(ctx, level, msg, fields...)
+}
+
+// SetLogger is an optional parameter for NewDuoApi which records every call
+// with logger: the call's method, path, status, number of attempts and
+// latency, each retry and its backoff, and any response which could not
+// be decoded.  Only the names of a call's parameters are logged, unless
+// SetLogParams is also given; secret keys and Authorization headers are
+// never logged.
+//
+// Example: duoapi.NewDuoApi(ikey,skey,host,userAgent,duoapi.SetLogger(duoapi.NewSlogLogger(slog.Default())))
+func SetLogger(logger Logger) func(*apiOptions) {
+	return func(opts *apiOptions) {
+		opts.logger = logger
+	}
+}
+
+// SetLogParams is an optional parameter for NewDuoApi which logs the values
+// of calls' parameters as well as their names.  Passwords, passcodes and
+// other secrets among them are still redacted.
+func SetLogParams() func(*apiOptions) {
+	return func(opts *apiOptions) {
+		opts.logParams = true
+	}
+}
+
+// logParams returns params as they are logged.
+func (duoapi *DuoApi) logParams(params url.Values) string {
+	logged := url.Values{}
+	for key, values := range params {
+		if duoapi.logParamValues && !sensitiveFields[key] {
+			logged[key] = values
+		} else {
+			logged[key] = []string{Redacted}
+		}
+	}
+	return logged.Encode()
+}
+
+// logError returns err as it is logged.  Errors from the HTTP client name
+// the request's URL, whose query holds the call's parameters.
+func (duoapi *DuoApi) logError(err error) string {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err.Error()
+	}
+	logged := *urlErr
+	if u, parseErr := url.Parse(urlErr.URL); parseErr == nil {
+		u.RawQuery = duoapi.logParams(u.Query())
+		logged.URL = u.String()
+	} else {
+		logged.URL = Redacted
+	}
+	return logged.Error()
+}
+
+// logCall logs the outcome of a call after its last attempt.
+func (duoapi *DuoApi) logCall(ctx context.Context,
+	method string,
+	uri string,
+	params url.Values,
+	attempts int,
+	latency time.Duration,
+	resp *http.Response,
+	err error) {
+	if duoapi.logger == nil {
+		return
+	}
+	fields := []LogField{
+		{"method", method},
+		{"path", uri},
+		{"params", duoapi.logParams(params)},
+		{"attempts", attempts},
+		{"latency", latency},
+	}
+	level, msg := LevelDebug, "duoapi: call"
+	if resp != nil {
+		fields = append(fields, LogField{"status", resp.StatusCode})
+		if resp.StatusCode >= 400 {
+			level, msg = LevelWarn, "duoapi: call failed"
+		}
+	}
+	if err != nil {
+		fields = append(fields, LogField{"error", duoapi.logError(err)})
+		level, msg = LevelError, "duoapi: call failed"
+	}
+	duoapi.logger.Log(ctx, level, msg, fields...)
+}
+
+// logRetry logs an attempt which is about to be retried after backoff.
+func (duoapi *DuoApi) logRetry(ctx context.Context,
+	method string,
+	uri string,
+	attempt int,
+	latency time.Duration,
+	backoff time.Duration,
+	resp *http.Response,
+	err error) {
+	if duoapi.logger == nil {
+		return
+	}
+	fields := []LogField{
+		{"method", method},
+		{"path", uri},
+		{"attempt", attempt},
+		{"latency", latency},
+		{"backoff", backoff},
+	}
+	if resp != nil {
+		fields = append(fields, LogField{"status", resp.StatusCode})
+	}
+	if err != nil {
+		fields = append(fields, LogField{"error", duoapi.logError(err)})
+	}
+	duoapi.logger.Log(ctx, LevelWarn, "duoapi: retrying", fields...)
+}
+
+// logDecodeError logs a response to a call which could not be decoded.
+func (duoapi *DuoApi) logDecodeError(ctx context.Context, method string, uri string, resp *http.Response, err error) {
+	if duoapi.logger == nil {
+		return
+	}
+	duoapi.logger.Log(ctx, LevelError, "duoapi: decoding response failed",
+		LogField{"method", method},
+		LogField{"path", uri},
+		LogField{"status", resp.StatusCode},
+		LogField{"error", err.Error()})
+}
@@ -0,0 +1,44 @@
+//go:build go1.21
+// +build go1.21
+
+package duoapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSlogLogger(t *testing.T) {
+	duo, _, _ := getMockClients([]http.Response{rateLimitResp, okResp})
+	var out bytes.Buffer
+	duo.logger = NewSlogLogger(slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	if _, _, err := duo.SignedCall("GET", "/auth/v2/check", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected two log lines, but got:\n%s", out.String())
+	}
+	var retry, call map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &retry); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &call); err != nil {
+		t.Fatal(err)
+	}
+	if retry["level"] != "WARN" || retry["backoff"] != float64(1e9) || retry["status"] != float64(429) {
+		t.Errorf("Unexpected retry line %v", retry)
+	}
+	if call["level"] != "DEBUG" || call["path"] != "/auth/v2/check" || call["attempts"] != float64(2) {
+		t.Errorf("Unexpected call line %v", call)
+	}
+	if strings.Contains(out.String(), "skey-bar") {
+		t.Errorf("Secret key in log output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,131 @@
+package duoapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type logEntry struct {
+	level  LogLevel
+	msg    string
+	fields map[string]interface{}
+}
+
+// recordingLogger is a Logger which keeps every entry it is given.
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *recordingLogger) Log(ctx context.Context, level LogLevel, msg string, fields ...LogField) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry := logEntry{level: level, msg: msg, fields: map[string]interface{}{}}
+	for _, field := range fields {
+		entry.fields[field.Key] = field.Value
+	}
+	l.entries = append(l.entries, entry)
+}
+
+func TestLoggerRecordsRetriesAndCalls(t *testing.T) {
+	duo, _, _ := getMockClients([]http.Response{rateLimitResp, okResp})
+	logger := &recordingLogger{}
+	duo.logger = logger
+
+	params := url.Values{"username": []string{"jsmith"}, "passcode": []string{"123456"}}
+	if _, _, err := duo.SignedCall("POST", "/auth/v2/auth", params); err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.entries) != 2 {
+		t.Fatalf("Expected a retry and a call to be logged, but got %+v", logger.entries)
+	}
+
+	retry := logger.entries[0]
+	if retry.level != LevelWarn || retry.msg != "duoapi: retrying" {
+		t.Errorf("Unexpected retry entry %+v", retry)
+	}
+	if retry.fields["attempt"] != 1 || retry.fields["status"] != 429 || retry.fields["backoff"] != time.Second {
+		t.Errorf("Unexpected retry fields %+v", retry.fields)
+	}
+
+	call := logger.entries[1]
+	if call.level != LevelDebug || call.msg != "duoapi: call" {
+		t.Errorf("Unexpected call entry %+v", call)
+	}
+	if call.fields["method"] != "POST" || call.fields["path"] != "/auth/v2/auth" ||
+		call.fields["attempts"] != 2 || call.fields["status"] != 200 {
+		t.Errorf("Unexpected call fields %+v", call.fields)
+	}
+	if _, ok := call.fields["latency"].(time.Duration); !ok {
+		t.Errorf("Expected the call's latency, but got %+v", call.fields)
+	}
+	if call.fields["params"] != "passcode=REDACTED&username=REDACTED" {
+		t.Errorf("Expected redacted params, but got %q", call.fields["params"])
+	}
+}
+
+func TestLogParams(t *testing.T) {
+	duo, _, _ := getMockClients([]http.Response{okResp})
+	logger := &recordingLogger{}
+	duo.logger = logger
+	duo.logParamValues = true
+
+	params := url.Values{"username": []string{"jsmith"}, "passcode": []string{"123456"}}
+	if _, _, err := duo.SignedCall("POST", "/auth/v2/auth", params); err != nil {
+		t.Fatal(err)
+	}
+	if got := logger.entries[0].fields["params"]; got != "passcode=REDACTED&username=jsmith" {
+		t.Errorf("Expected only the passcode to be redacted, but got %q", got)
+	}
+}
+
+func TestLoggerRecordsFailures(t *testing.T) {
+	duo, _, _ := getMockClients([]http.Response{
+		{StatusCode: 404, Body: &closeTrackingBody{Reader: strings.NewReader(`{"stat": "FAIL", "code": 40401}`)}},
+		{StatusCode: 200, Body: &closeTrackingBody{Reader: strings.NewReader(`{"stat": "OK", "response": [}`)}},
+	})
+	logger := &recordingLogger{}
+	duo.logger = logger
+
+	if err := duo.SignedCallInto(context.Background(), "GET", "/admin/v1/users/DU123", nil, &StatResult{}); !IsNotFound(err) {
+		t.Fatalf("Expected not found, but got %v", err)
+	}
+	if len(logger.entries) != 1 || logger.entries[0].level != LevelWarn || logger.entries[0].fields["status"] != 404 {
+		t.Fatalf("Expected only a warning for the failed call, but got %+v", logger.entries)
+	}
+
+	if err := duo.SignedCallInto(context.Background(), "GET", "/admin/v1/users", nil, &StatResult{}); err == nil {
+		t.Fatal("Expected a decode error")
+	}
+	decode := logger.entries[len(logger.entries)-1]
+	if decode.level != LevelError || decode.msg != "duoapi: decoding response failed" || decode.fields["path"] != "/admin/v1/users" {
+		t.Errorf("Unexpected decode failure entry %+v", decode)
+	}
+}
+
+func TestLoggerRedactsURLErrors(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	base, _ := url.Parse(ts.URL)
+	ts.Close()
+
+	logger := &recordingLogger{}
+	duo := NewDuoApi("ABC", "123", "api-test.duosecurity.com", "go-client",
+		SetBaseURL(base), SetLogger(logger))
+	params := url.Values{"username": []string{"jsmith"}}
+	if _, _, err := duo.SignedCall("GET", "/admin/v1/users", params); err == nil {
+		t.Fatal("Expected a connection error")
+	}
+
+	entry := logger.entries[len(logger.entries)-1]
+	logged := fmt.Sprint(entry.fields["error"])
+	if entry.level != LevelError || strings.Contains(logged, "jsmith") || !strings.Contains(logged, "username=REDACTED") {
+		t.Errorf("Expected a redacted error, but got %+v", entry)
+	}
+}
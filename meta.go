@@ -0,0 +1,63 @@
+package duoapi
+
+import (
+	"net/http"
+	"time"
+)
+
+// ResponseMeta describes the HTTP response to a call, which the result types
+// of the authapi and admin packages otherwise hide.
+type ResponseMeta struct {
+	// StatusCode and Header are those of the last attempt's response.
+	StatusCode int
+	Header     http.Header
+	// Date is the server's time from the Date header, or zero if it was
+	// missing.
+	Date time.Time
+	// Retries is the number of attempts made after the first.
+	Retries int
+	// Elapsed is the time taken by the call, including any retries.
+	Elapsed time.Duration
+}
+
+// NewResponseMeta returns the ResponseMeta of resp, the response to a call
+// which took attempts attempts and elapsed time.
+func NewResponseMeta(resp *http.Response, attempts int, elapsed time.Duration) ResponseMeta {
+	meta := ResponseMeta{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Elapsed:    elapsed,
+	}
+	if attempts > 1 {
+		meta.Retries = attempts - 1
+	}
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		meta.Date = date
+	}
+	return meta
+}
+
+// ReportResponseMeta is an optional parameter for the Call functions which
+// stores the ResponseMeta of the call's response in meta.  meta is left
+// unchanged if the call failed without a response.
+//
+// Example: duo.SignedCall("GET", "/auth/v2/check", nil, duoapi.ReportResponseMeta(&meta))
+func ReportResponseMeta(meta *ResponseMeta) DuoApiOption {
+	return func(opts *requestOptions) {
+		opts.meta = append(opts.meta, meta)
+	}
+}
+
+// metaSetter is implemented by results which embed StatResult.
+type metaSetter interface {
+	setResponseMeta(meta ResponseMeta)
+}
+
+func (s *StatResult) setResponseMeta(meta ResponseMeta) {
+	s.Meta = meta
+}
+
+// withResponseMeta returns options with meta reported.
+func withResponseMeta(meta *ResponseMeta, options []DuoApiOption) []DuoApiOption {
+	return append(options[:len(options):len(options)], ReportResponseMeta(meta))
+}
@@ -0,0 +1,49 @@
+package duoapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResponseMeta(t *testing.T) {
+	date := "Tue, 04 Jul 2023 12:00:00 GMT"
+	duo, _, _ := getMockClients([]http.Response{
+		{StatusCode: 429, Body: &closeTrackingBody{Reader: strings.NewReader("")}},
+		{
+			StatusCode: 200,
+			Header:     http.Header{"Date": []string{date}, "X-Request-Id": []string{"abc"}},
+			Body:       &closeTrackingBody{Reader: strings.NewReader(`{"stat": "OK", "response": "pong"}`)},
+		},
+	})
+
+	result := &ikeyResult{}
+	var reported ResponseMeta
+	err := duo.SignedCallInto(context.Background(), "GET", "/auth/v2/check", nil, result, ReportResponseMeta(&reported))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meta := result.Meta
+	if meta.StatusCode != 200 || meta.Retries != 1 || meta.Header.Get("X-Request-Id") != "abc" {
+		t.Errorf("Unexpected meta %+v", meta)
+	}
+	if want, _ := time.Parse(time.RFC1123, date); !meta.Date.Equal(want) {
+		t.Errorf("Expected date %v, but got %v", want, meta.Date)
+	}
+	if meta.Elapsed <= 0 {
+		t.Errorf("Expected the elapsed time, but got %v", meta.Elapsed)
+	}
+	if reported.StatusCode != 200 || reported.Retries != 1 {
+		t.Errorf("Expected the caller's meta to be filled too, but got %+v", reported)
+	}
+}
+
+func TestResponseMetaWithoutDate(t *testing.T) {
+	meta := NewResponseMeta(&http.Response{StatusCode: 404, Header: http.Header{}}, 1, time.Second)
+	if !meta.Date.IsZero() || meta.Retries != 0 || meta.StatusCode != 404 {
+		t.Errorf("Unexpected meta %+v", meta)
+	}
+}
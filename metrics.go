@@ -0,0 +1,300 @@
+package duoapi
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CallStats describes a finished call, for an Observer.
+type CallStats struct {
+	Method string
+	// Endpoint is the call's URI with IDs replaced by placeholders, as
+	// returned by EndpointTemplate.
+	Endpoint string
+	// Status is the HTTP status of the last attempt's response, or 0 if it
+	// failed without one.
+	Status int
+	// Attempts is the number of attempts made.
+	Attempts int
+	// Latency is the time from the start of the first attempt to the end of
+	// the last, including any retry sleeps.
+	Latency time.Duration
+	// RateLimited is the number of attempts answered with HTTP 429.
+	RateLimited int
+	// RetrySleep is the time spent sleeping between attempts.
+	RetrySleep time.Duration
+	// Err is the error the call returned, if any.
+	Err error
+}
+
+// Observer is told about every call, e.g. to record metrics.  Its methods
+// must be safe for concurrent use.
+type Observer interface {
+	// CallStarted is called before the first attempt at a call.
+	CallStarted(ctx context.Context, method string, endpoint string)
+	// CallFinished is called after the last attempt.
+	CallFinished(ctx context.Context, stats CallStats)
+}
+
+// SetObserver is an optional parameter for NewDuoApi which reports every
+// call to observer.
+//
+// Example: duoapi.NewDuoApi(ikey,skey,host,userAgent,duoapi.SetObserver(metrics))
+func SetObserver(observer Observer) func(*apiOptions) {
+	return func(opts *apiOptions) {
+		opts.observer = observer
+	}
+}
+
+// EndpointTemplate returns uri with the IDs in it replaced by placeholders
+// named after the collection they belong to, so that calls to the same
+// endpoint share metrics.  Segments other than lowercase words, such as
+// "DU3RP9I2WOC59VZX672N", are taken to be IDs.
+//
+// Example: EndpointTemplate("/admin/v1/users/DU3RP9I2WOC59VZX672N/phones") returns "/admin/v1/users/:user_id/phones"
+func EndpointTemplate(uri string) string {
+	segments := strings.Split(uri, "/")
+	for i, segment := range segments {
+		if segment == "" || isStaticSegment(segment) {
+			continue
+		}
+		name := "id"
+		if i > 0 && isStaticSegment(segments[i-1]) {
+			name = singular(segments[i-1]) + "_id"
+		}
+		segments[i] = ":" + name
+	}
+	return strings.Join(segments, "/")
+}
+
+// isStaticSegment reports whether a path segment is a lowercase word, such
+// as "users", "v1" or "bypass_codes", rather than an ID.
+func isStaticSegment(segment string) bool {
+	if segment == "" || segment[0] < 'a' || segment[0] > 'z' {
+		return false
+	}
+	for _, c := range segment {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func singular(collection string) string {
+	switch {
+	case strings.HasSuffix(collection, "ies"):
+		return strings.TrimSuffix(collection, "ies") + "y"
+	case strings.HasSuffix(collection, "ss"):
+		return collection
+	}
+	return strings.TrimSuffix(collection, "s")
+}
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the latency
+// histogram buckets used by NewMetrics when none are given.  They run to a
+// minute, since an Auth API call may wait on the user to approve a push.
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+// Metrics is an Observer which counts calls in memory and renders them in
+// the Prometheus text exposition format.  It serves that format as an
+// http.Handler, so it can be mounted at /metrics.
+type Metrics struct {
+	buckets []float64
+
+	mu          sync.Mutex
+	inFlight    map[endpointKey]int64
+	calls       map[callKey]int64
+	latency     map[endpointKey]*histogram
+	rateLimited map[endpointKey]int64
+	retrySleep  map[endpointKey]float64
+}
+
+type endpointKey struct {
+	method   string
+	endpoint string
+}
+
+type callKey struct {
+	endpointKey
+	status string
+}
+
+type histogram struct {
+	counts []int64
+	count  int64
+	sum    float64
+}
+
+// NewMetrics returns an empty Metrics whose latency histograms have the
+// given bucket upper bounds in seconds, or DefaultLatencyBuckets if none
+// are given.
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Metrics{
+		buckets:     buckets,
+		inFlight:    make(map[endpointKey]int64),
+		calls:       make(map[callKey]int64),
+		latency:     make(map[endpointKey]*histogram),
+		rateLimited: make(map[endpointKey]int64),
+		retrySleep:  make(map[endpointKey]float64),
+	}
+}
+
+// CallStarted implements Observer.
+func (m *Metrics) CallStarted(ctx context.Context, method string, endpoint string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight[endpointKey{method, endpoint}]++
+}
+
+// CallFinished implements Observer.
+func (m *Metrics) CallFinished(ctx context.Context, stats CallStats) {
+	key := endpointKey{stats.Method, stats.Endpoint}
+	status := "error"
+	if stats.Status != 0 {
+		status = strconv.Itoa(stats.Status)
+	}
+	seconds := stats.Latency.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight[key]--
+	m.calls[callKey{key, status}]++
+	h := m.latency[key]
+	if h == nil {
+		h = &histogram{counts: make([]int64, len(m.buckets))}
+		m.latency[key] = h
+	}
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+	if stats.RateLimited > 0 {
+		m.rateLimited[key] += int64(stats.RateLimited)
+	}
+	if stats.RetrySleep > 0 {
+		m.retrySleep[key] += stats.RetrySleep.Seconds()
+	}
+}
+
+// WritePrometheus writes the metrics to w in the Prometheus text exposition
+// format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := bufio.NewWriter(w)
+	header := func(name, kind, help string) {
+		fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	header("duoapi_requests_in_flight", "gauge", "Duo API calls in progress.")
+	for _, key := range sortedEndpoints(m.inFlight) {
+		fmt.Fprintf(out, "duoapi_requests_in_flight{%s} %d\n", key.labels(), m.inFlight[key])
+	}
+
+	header("duoapi_requests_total", "counter", "Duo API calls by endpoint and final HTTP status.")
+	calls := make([]callKey, 0, len(m.calls))
+	for key := range m.calls {
+		calls = append(calls, key)
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].endpointKey != calls[j].endpointKey {
+			return calls[i].endpointKey.less(calls[j].endpointKey)
+		}
+		return calls[i].status < calls[j].status
+	})
+	for _, key := range calls {
+		fmt.Fprintf(out, "duoapi_requests_total{%s,status=%s} %d\n", key.labels(), quoteLabel(key.status), m.calls[key])
+	}
+
+	header("duoapi_request_duration_seconds", "histogram", "Duo API call latency, including retries.")
+	latencies := make([]endpointKey, 0, len(m.latency))
+	for key := range m.latency {
+		latencies = append(latencies, key)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i].less(latencies[j]) })
+	for _, key := range latencies {
+		h := m.latency[key]
+		for i, bound := range m.buckets {
+			fmt.Fprintf(out, "duoapi_request_duration_seconds_bucket{%s,le=%s} %d\n", key.labels(), quoteLabel(formatFloat(bound)), h.counts[i])
+		}
+		fmt.Fprintf(out, "duoapi_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", key.labels(), h.count)
+		fmt.Fprintf(out, "duoapi_request_duration_seconds_sum{%s} %s\n", key.labels(), formatFloat(h.sum))
+		fmt.Fprintf(out, "duoapi_request_duration_seconds_count{%s} %d\n", key.labels(), h.count)
+	}
+
+	header("duoapi_rate_limited_total", "counter", "Duo API attempts answered with HTTP 429.")
+	for _, key := range sortedEndpoints(m.rateLimited) {
+		fmt.Fprintf(out, "duoapi_rate_limited_total{%s} %d\n", key.labels(), m.rateLimited[key])
+	}
+
+	header("duoapi_retry_sleep_seconds_total", "counter", "Time spent sleeping between Duo API call attempts.")
+	sleeps := make([]endpointKey, 0, len(m.retrySleep))
+	for key := range m.retrySleep {
+		sleeps = append(sleeps, key)
+	}
+	sort.Slice(sleeps, func(i, j int) bool { return sleeps[i].less(sleeps[j]) })
+	for _, key := range sleeps {
+		fmt.Fprintf(out, "duoapi_retry_sleep_seconds_total{%s} %s\n", key.labels(), formatFloat(m.retrySleep[key]))
+	}
+
+	return out.Flush()
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WritePrometheus(w)
+}
+
+func (k endpointKey) less(other endpointKey) bool {
+	if k.endpoint != other.endpoint {
+		return k.endpoint < other.endpoint
+	}
+	return k.method < other.method
+}
+
+func (k endpointKey) labels() string {
+	return "method=" + quoteLabel(k.method) + ",endpoint=" + quoteLabel(k.endpoint)
+}
+
+func sortedEndpoints(counts map[endpointKey]int64) []endpointKey {
+	keys := make([]endpointKey, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].less(keys[j]) })
+	return keys
+}
+
+// quoteLabel quotes a label value, escaping backslashes, double quotes and
+// newlines as the exposition format requires.
+func quoteLabel(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return `"` + value + `"`
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,112 @@
+package duoapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEndpointTemplate(t *testing.T) {
+	tests := map[string]string{
+		"/auth/v2/preauth":                                      "/auth/v2/preauth",
+		"/admin/v1/users":                                       "/admin/v1/users",
+		"/admin/v1/users/DU3RP9I2WOC59VZX672N":                  "/admin/v1/users/:user_id",
+		"/admin/v1/users/DU3RP9I2WOC59VZX672N/bypass_codes":     "/admin/v1/users/:user_id/bypass_codes",
+		"/admin/v1/users/DU3RP9I2WOC59VZX672N/groups/DGXXXXXXX": "/admin/v1/users/:user_id/groups/:group_id",
+		"/admin/v2/policies/POABC123":                           "/admin/v2/policies/:policy_id",
+		"/admin/v1/integrations/DIWJ8X6AEYOR5OMC6TQ1":           "/admin/v1/integrations/:integration_id",
+		"/admin/v1/phones/DP1/send_sms_activation":              "/admin/v1/phones/:phone_id/send_sms_activation",
+		"/frame/web/v1/auth/12345":                              "/frame/web/v1/auth/:auth_id",
+	}
+	for uri, want := range tests {
+		if got := EndpointTemplate(uri); got != want {
+			t.Errorf("EndpointTemplate(%q) = %q, want %q", uri, got, want)
+		}
+	}
+}
+
+// realSleepService sleeps for a fixed short time, whatever it is asked.
+type realSleepService struct{}
+
+func (realSleepService) Sleep(ctx context.Context, duration time.Duration) error {
+	time.Sleep(time.Millisecond)
+	return nil
+}
+
+func (svc realSleepService) SleepExact(ctx context.Context, duration time.Duration) error {
+	return svc.Sleep(ctx, duration)
+}
+
+func TestMetrics(t *testing.T) {
+	duo, _, _ := getMockClients([]http.Response{
+		{StatusCode: 429, Body: &closeTrackingBody{Reader: strings.NewReader("")}},
+		{StatusCode: 200, Body: &closeTrackingBody{Reader: strings.NewReader("")}},
+		{StatusCode: 404, Body: &closeTrackingBody{Reader: strings.NewReader("")}},
+	})
+	duo.sleepSvc = realSleepService{}
+	metrics := NewMetrics(0.5, 0.1)
+	duo.observer = metrics
+
+	for _, id := range []string{"DU0000000000000000001", "DU0000000000000000002"} {
+		if _, _, err := duo.SignedCall("GET", "/admin/v1/users/"+id, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	metrics.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+	out := w.Body.String()
+
+	labels := `method="GET",endpoint="/admin/v1/users/:user_id"`
+	for _, line := range []string{
+		"# TYPE duoapi_requests_total counter",
+		"duoapi_requests_in_flight{" + labels + "} 0",
+		"duoapi_requests_total{" + labels + `,status="200"} 1`,
+		"duoapi_requests_total{" + labels + `,status="404"} 1`,
+		"# TYPE duoapi_request_duration_seconds histogram",
+		"duoapi_request_duration_seconds_bucket{" + labels + `,le="0.1"} 2`,
+		"duoapi_request_duration_seconds_bucket{" + labels + `,le="0.5"} 2`,
+		"duoapi_request_duration_seconds_bucket{" + labels + `,le="+Inf"} 2`,
+		"duoapi_request_duration_seconds_count{" + labels + "} 2",
+		"duoapi_rate_limited_total{" + labels + "} 1",
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("Expected %q in:\n%s", line, out)
+		}
+	}
+	if !strings.Contains(out, "duoapi_retry_sleep_seconds_total{"+labels+"} 0.00") {
+		t.Errorf("Expected the retry sleep time in:\n%s", out)
+	}
+	if strings.Contains(out, "DU000") {
+		t.Errorf("Expected no raw IDs in:\n%s", out)
+	}
+}
+
+func TestMetricsTransportError(t *testing.T) {
+	duo, httpClient, _ := getMockClients(nil)
+	httpClient.doError = true
+	metrics := NewMetrics()
+	duo.observer = metrics
+
+	duo.Call("GET", "/auth/v2/ping", nil)
+
+	var out strings.Builder
+	if err := metrics.WritePrometheus(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `duoapi_requests_total{method="GET",endpoint="/auth/v2/ping",status="error"} 1`) {
+		t.Errorf("Expected the failed call to be counted, but got:\n%s", out.String())
+	}
+}
+
+func TestQuoteLabel(t *testing.T) {
+	if got := quoteLabel("a\"b\\c\nd"); got != `"a\"b\\c\nd"` {
+		t.Errorf("Unexpected quoted label %s", got)
+	}
+}
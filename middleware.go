@@ -0,0 +1,81 @@
+package duoapi
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// Request describes one attempt at a Duo API call, as seen by Middleware.
+type Request struct {
+	// Method, URI and Params are the arguments the call was made with.  For
+	// JSON calls, Params holds the query parameters.  Params should not be
+	// modified: HTTPRequest has already been built from it.
+	Method string
+	URI    string
+	Params url.Values
+	// Attempt is the number of this attempt, counting from 1.
+	Attempt int
+	// Stream is set for calls made with StreamResponse.  Their Handler
+	// returns a nil body, leaving the response's Body unread.
+	Stream bool
+	// HTTPRequest is the signed request to be sent.  Middleware may add
+	// headers such as correlation IDs, but they are not covered by the
+	// signature, so X-Duo-* headers must not be added to SignatureV5 calls.
+	HTTPRequest *http.Request
+}
+
+// Handler makes one attempt at a call, returning the response and its body,
+// which is nil for streamed calls.
+type Handler func(req *Request) (*http.Response, []byte, error)
+
+// Middleware wraps a Handler.  It may inspect or change the request before
+// calling next, inspect the response and body afterward, or return a result
+// of its own without calling next at all.  Middleware runs once for every
+// attempt, and the retry policy sees the result it returns.
+type Middleware func(next Handler) Handler
+
+// errNoResult is returned when middleware returns neither a response nor an
+// error.
+var errNoResult = errors.New("duoapi: middleware returned no response")
+
+// SetMiddleware is an optional parameter for NewDuoApi which wraps every
+// attempt at a call in middleware.  The first middleware given is the
+// outermost.
+//
+// Example: duoapi.NewDuoApi(ikey,skey,host,userAgent,duoapi.SetMiddleware(logCalls))
+func SetMiddleware(middleware ...Middleware) func(*apiOptions) {
+	return func(opts *apiOptions) {
+		opts.middleware = append(opts.middleware, middleware...)
+	}
+}
+
+// chain wraps h in the DuoApi's middleware.
+func (duoapi *DuoApi) chain(h Handler) Handler {
+	for i := len(duoapi.middleware) - 1; i >= 0; i-- {
+		h = duoapi.middleware[i](h)
+	}
+	return h
+}
+
+// send returns the Handler which sends a request with client, reading at
+// most maxSize bytes of the response if maxSize is positive.
+func send(client httpClient, maxSize int64) Handler {
+	return func(req *Request) (*http.Response, []byte, error) {
+		resp, err := client.Do(req.HTTPRequest)
+		if err != nil {
+			return resp, nil, err
+		}
+		if err := limitBody(req, resp, maxSize); err != nil {
+			resp.Body.Close()
+			return resp, nil, err
+		}
+		if req.Stream {
+			return resp, nil, nil
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return resp, body, err
+	}
+}
@@ -0,0 +1,114 @@
+package duoapi
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestMiddlewareSeesEveryAttempt(t *testing.T) {
+	// Fresh bodies, since the shared responses' readers may be drained.
+	newResp := func(status int) http.Response {
+		return http.Response{
+			StatusCode: status,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte("hello world"))),
+		}
+	}
+	duo, mockHttp, _ := getMockClients([]http.Response{newResp(429), newResp(200)})
+
+	var calls []string
+	var attempts []int
+	var statuses []int
+	outer := func(next Handler) Handler {
+		return func(req *Request) (*http.Response, []byte, error) {
+			calls = append(calls, "outer")
+			req.HTTPRequest.Header.Set("X-Correlation-Id", "abc123")
+			return next(req)
+		}
+	}
+	inner := func(next Handler) Handler {
+		return func(req *Request) (*http.Response, []byte, error) {
+			calls = append(calls, "inner")
+			if req.Method != "GET" || req.URI != "/v9/hello/world" || req.Params.Get("a") != "b" {
+				t.Errorf("Unexpected request %s %s %v", req.Method, req.URI, req.Params)
+			}
+			attempts = append(attempts, req.Attempt)
+			resp, body, err := next(req)
+			statuses = append(statuses, resp.StatusCode)
+			if string(body) != "hello world" {
+				t.Errorf("Middleware saw body %q", body)
+			}
+			return resp, body, err
+		}
+	}
+	duo.middleware = []Middleware{outer, inner}
+
+	_, _, err := duo.SignedCall("GET", "/v9/hello/world", url.Values{"a": []string{"b"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(calls, []string{"outer", "inner", "outer", "inner"}) {
+		t.Errorf("Middleware ran in order %v", calls)
+	}
+	if !reflect.DeepEqual(attempts, []int{1, 2}) {
+		t.Errorf("Middleware saw attempts %v", attempts)
+	}
+	if !reflect.DeepEqual(statuses, []int{429, 200}) {
+		t.Errorf("Middleware saw statuses %v", statuses)
+	}
+	for _, req := range mockHttp.actualRequests {
+		if req.Header.Get("X-Correlation-Id") != "abc123" {
+			t.Error("Header added by middleware was not sent")
+		}
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	duo, mockHttp, _ := getMockClients(nil)
+	cached := &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+	}
+	duo.middleware = []Middleware{func(next Handler) Handler {
+		return func(req *Request) (*http.Response, []byte, error) {
+			return cached, []byte(`{"stat":"OK"}`), nil
+		}
+	}}
+
+	resp, body, err := duo.Call("GET", "/auth/v2/ping", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp != cached || string(body) != `{"stat":"OK"}` {
+		t.Error("Short-circuited result was not returned")
+	}
+	if len(mockHttp.actualRequests) != 0 {
+		t.Error("Short-circuited call reached the HTTP client")
+	}
+}
+
+func TestMiddlewareNoResult(t *testing.T) {
+	duo, _, _ := getMockClients(nil)
+	duo.middleware = []Middleware{func(next Handler) Handler {
+		return func(req *Request) (*http.Response, []byte, error) {
+			return nil, nil, nil
+		}
+	}}
+
+	_, _, err := duo.Call("GET", "/auth/v2/ping", nil)
+	if err != errNoResult {
+		t.Fatalf("Expected errNoResult, but got %v", err)
+	}
+}
+
+func TestSetMiddleware(t *testing.T) {
+	noop := func(next Handler) Handler { return next }
+	duo := NewDuoApi("ABC", "123", "api-XXXXXXX.duosecurity.com", "go-client",
+		SetMiddleware(noop), SetMiddleware(noop, noop))
+	if len(duo.middleware) != 3 {
+		t.Fatalf("Expected 3 middleware, but got %d", len(duo.middleware))
+	}
+}
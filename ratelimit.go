@@ -0,0 +1,176 @@
+package duoapi
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit is a token bucket budget for the calls whose URI starts with
+// Prefix.  Rate is the sustained number of calls per second, and Burst the
+// number which may be made at once after a quiet period; it is at least 1.
+type RateLimit struct {
+	Prefix string
+	Rate   float64
+	Burst  int
+}
+
+// RateLimitMode selects what a RateLimiter does with a call that is over
+// budget.
+type RateLimitMode int
+
+const (
+	// RateLimitBlock delays the call until it is within budget, or its
+	// context is done.
+	RateLimitBlock RateLimitMode = iota
+	// RateLimitFailFast fails the call with ErrRateLimitExceeded.
+	RateLimitFailFast
+)
+
+// ErrRateLimitExceeded is returned for calls refused by a RateLimiter in
+// RateLimitFailFast mode.  It matches ErrRateLimited, so IsRateLimited
+// reports true for it.
+var ErrRateLimitExceeded error = rateLimitExceeded{}
+
+type rateLimitExceeded struct{}
+
+func (rateLimitExceeded) Error() string {
+	return "duoapi: client rate limit exceeded"
+}
+
+func (rateLimitExceeded) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RateLimiter limits calls to per-endpoint budgets on the client side, so
+// that batch jobs stay under Duo's rate limits rather than backing off from
+// 429 responses.  It is safe for concurrent use; pass the same RateLimiter
+// to every DuoApi which uses the same credentials to share its budgets.
+type RateLimiter struct {
+	mode   RateLimitMode
+	limits []RateLimit
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+
+	now   func() time.Time
+	sleep sleepService
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a RateLimiter enforcing limits.  A call is limited
+// by the RateLimit with the longest Prefix it matches; calls which match
+// none are not limited.
+func NewRateLimiter(mode RateLimitMode, limits ...RateLimit) *RateLimiter {
+	sorted := append([]RateLimit{}, limits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Prefix) > len(sorted[j].Prefix)
+	})
+	return &RateLimiter{
+		mode:    mode,
+		limits:  sorted,
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+		sleep:   timeSleepService{},
+	}
+}
+
+// clone returns a RateLimiter with l's mode and limits, but full budgets.
+func (l *RateLimiter) clone() *RateLimiter {
+	return &RateLimiter{
+		mode:    l.mode,
+		limits:  l.limits,
+		buckets: make(map[string]*tokenBucket),
+		now:     l.now,
+		sleep:   l.sleep,
+	}
+}
+
+// Wait takes a token for a call to uri, waiting for one if the limiter
+// blocks.  It returns ErrRateLimitExceeded if the limiter fails fast and no
+// token is available, or the context's error if ctx is done first.
+func (l *RateLimiter) Wait(ctx context.Context, uri string) error {
+	return l.wait(ctx, uri, l.sleep)
+}
+
+// wait is like Wait, but waits with sleep, e.g. the sleepService of the
+// DuoApi making the call.
+func (l *RateLimiter) wait(ctx context.Context, uri string, sleep sleepService) error {
+	limit, ok := l.limitFor(uri)
+	if !ok || limit.Rate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	b := l.bucket(limit)
+	if l.mode == RateLimitFailFast && b.tokens < 1 {
+		l.mu.Unlock()
+		return ErrRateLimitExceeded
+	}
+	// Reserve a token, going into debt if none is available yet.
+	b.tokens--
+	wait := time.Duration(0)
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / limit.Rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+	// The token is already reserved, so jitter would only lower the rate.
+	if err := sleep.SleepExact(ctx, wait); err != nil {
+		// Return the unused reservation.
+		l.mu.Lock()
+		l.bucket(limit).tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (l *RateLimiter) limitFor(uri string) (RateLimit, bool) {
+	for _, limit := range l.limits {
+		if strings.HasPrefix(uri, limit.Prefix) {
+			return limit, true
+		}
+	}
+	return RateLimit{}, false
+}
+
+// bucket returns the refilled bucket for limit.  l.mu must be held.
+func (l *RateLimiter) bucket(limit RateLimit) *tokenBucket {
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	now := l.now()
+	b, ok := l.buckets[limit.Prefix]
+	if !ok {
+		b = &tokenBucket{tokens: burst, last: now}
+		l.buckets[limit.Prefix] = b
+		return b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * limit.Rate
+	if b.tokens > burst {
+		b.tokens = burst
+	}
+	b.last = now
+	return b
+}
+
+// SetRateLimiter is an optional parameter for NewDuoApi which makes every
+// attempt at a call, including retries, wait on limiter first.
+//
+// Example: duoapi.NewDuoApi(ikey,skey,host,userAgent,duoapi.SetRateLimiter(limiter))
+func SetRateLimiter(limiter *RateLimiter) func(*apiOptions) {
+	return func(opts *apiOptions) {
+		opts.limiter = limiter
+	}
+}
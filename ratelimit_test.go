@@ -0,0 +1,162 @@
+package duoapi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestRateLimiter returns a RateLimiter whose clock only moves when it
+// sleeps or the test advances it.
+func newTestRateLimiter(mode RateLimitMode, limits ...RateLimit) (*RateLimiter, *time.Time, *[]time.Duration) {
+	limiter := NewRateLimiter(mode, limits...)
+	now := time.Unix(1500000000, 0)
+	sleeps := []time.Duration{}
+	limiter.now = func() time.Time { return now }
+	limiter.sleep = sleepFunc(func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		now = now.Add(d)
+		return nil
+	})
+	return limiter, &now, &sleeps
+}
+
+// sleepFunc adapts a function to a sleepService.
+type sleepFunc func(ctx context.Context, d time.Duration) error
+
+func (f sleepFunc) Sleep(ctx context.Context, d time.Duration) error {
+	return f(ctx, d)
+}
+
+func (f sleepFunc) SleepExact(ctx context.Context, d time.Duration) error {
+	return f(ctx, d)
+}
+
+func TestRateLimiterFailFast(t *testing.T) {
+	limiter, now, _ := newTestRateLimiter(RateLimitFailFast,
+		RateLimit{Prefix: "/admin/", Rate: 1, Burst: 2})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := limiter.Wait(ctx, "/admin/v1/users"); err != nil {
+			t.Fatalf("Call %d within burst failed: %v", i+1, err)
+		}
+	}
+	err := limiter.Wait(ctx, "/admin/v1/users")
+	if err != ErrRateLimitExceeded {
+		t.Fatalf("Expected ErrRateLimitExceeded, but got %v", err)
+	}
+	if !IsRateLimited(err) {
+		t.Error("IsRateLimited did not match ErrRateLimitExceeded")
+	}
+
+	*now = now.Add(time.Second)
+	if err := limiter.Wait(ctx, "/admin/v1/users"); err != nil {
+		t.Fatalf("Call after refill failed: %v", err)
+	}
+	if err := limiter.Wait(ctx, "/auth/v2/ping"); err != nil {
+		t.Fatalf("Unlimited call failed: %v", err)
+	}
+}
+
+func TestRateLimiterBlocks(t *testing.T) {
+	limiter, _, sleeps := newTestRateLimiter(RateLimitBlock,
+		RateLimit{Prefix: "/admin/", Rate: 2, Burst: 1})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx, "/admin/v1/users"); err != nil {
+			t.Fatalf("Call %d failed: %v", i+1, err)
+		}
+	}
+	if len(*sleeps) != 2 || (*sleeps)[0] != 500*time.Millisecond || (*sleeps)[1] != 500*time.Millisecond {
+		t.Fatalf("Unexpected waits %v", *sleeps)
+	}
+}
+
+func TestRateLimiterCanceledWaitReturnsToken(t *testing.T) {
+	limiter, now, _ := newTestRateLimiter(RateLimitBlock,
+		RateLimit{Prefix: "/admin/", Rate: 1, Burst: 1})
+	if err := limiter.Wait(context.Background(), "/admin/v1/users"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx, "/admin/v1/users"); err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, but got %v", err)
+	}
+
+	*now = now.Add(time.Second)
+	limiter.mode = RateLimitFailFast
+	if err := limiter.Wait(context.Background(), "/admin/v1/users"); err != nil {
+		t.Fatalf("Canceled wait kept its token: %v", err)
+	}
+}
+
+func TestRateLimiterLongestPrefix(t *testing.T) {
+	limiter, _, _ := newTestRateLimiter(RateLimitFailFast,
+		RateLimit{Prefix: "/admin/", Rate: 1, Burst: 1},
+		RateLimit{Prefix: "/admin/v2/logs/authentication", Rate: 1, Burst: 1})
+	ctx := context.Background()
+
+	if err := limiter.Wait(ctx, "/admin/v2/logs/authentication"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := limiter.Wait(ctx, "/admin/v1/users"); err != nil {
+		t.Fatalf("Authentication log call used the /admin/ budget: %v", err)
+	}
+	if err := limiter.Wait(ctx, "/admin/v2/logs/authentication"); err != ErrRateLimitExceeded {
+		t.Fatalf("Expected ErrRateLimitExceeded, but got %v", err)
+	}
+}
+
+func TestSetRateLimiterShared(t *testing.T) {
+	limiter, _, _ := newTestRateLimiter(RateLimitFailFast,
+		RateLimit{Prefix: "/", Rate: 1, Burst: 1})
+	first, _, _ := getMockClients([]http.Response{okResp})
+	second, secondHttp, _ := getMockClients([]http.Response{okResp})
+	first.limiter = limiter
+	second.limiter = limiter
+
+	if _, _, err := first.SignedCall("GET", "/auth/v2/check", url.Values{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	_, _, err := second.SignedCall("GET", "/admin/v1/users", url.Values{})
+	if !IsRateLimited(err) {
+		t.Fatalf("Expected the shared budget to be exhausted, but got %v", err)
+	}
+	if len(secondHttp.actualRequests) != 0 {
+		t.Fatal("Refused call was sent")
+	}
+
+	duo := NewDuoApi("ABC", "123", "api-XXXXXXX.duosecurity.com", "go-client", SetRateLimiter(limiter))
+	if duo.limiter != limiter {
+		t.Fatal("SetRateLimiter failed to set the limiter")
+	}
+}
+
+func TestRateLimiterUsesDuoApiSleep(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitBlock, RateLimit{Prefix: "/", Rate: 0.5, Burst: 1})
+	now := time.Unix(1500000000, 0)
+	limiter.now = func() time.Time { return now }
+	duo, _, sleepSvc := getMockClients([]http.Response{okResp, okResp, okResp})
+	duo.limiter = limiter
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := duo.SignedCall("GET", "/auth/v2/check", url.Values{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	// Waits are exactly as long as the budget needs, without jitter.
+	expected := []time.Duration{2 * time.Second, 4 * time.Second}
+	if !reflect.DeepEqual(sleepSvc.sleepCalls, expected) {
+		t.Fatalf("Expected the DuoApi's sleep service to wait %v, but got %v", expected, sleepSvc.sleepCalls)
+	}
+}
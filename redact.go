@@ -0,0 +1,229 @@
+package duoapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secrets in formatted and dumped values.
+const Redacted = "REDACTED"
+
+// secret holds a secret key.  Keeping it behind a pointer to a string means
+// that fmt prints only an address, even when it formats a struct holding it
+// without calling that struct's String method.
+type secret string
+
+func newSecret(value string) *secret {
+	s := secret(value)
+	return &s
+}
+
+func (s *secret) get() string {
+	if s == nil {
+		return ""
+	}
+	return string(*s)
+}
+
+func (s secret) String() string {
+	return Redacted
+}
+
+func (s secret) GoString() string {
+	return Redacted
+}
+
+// String describes the DuoApi without its secret key.
+func (duoapi DuoApi) String() string {
+	return fmt.Sprintf("DuoApi{ikey: %s, skey: %s, host: %s}", duoapi.ikey, Redacted, duoapi.host)
+}
+
+// GoString is like String, for the %#v verb.
+func (duoapi DuoApi) GoString() string {
+	return fmt.Sprintf("duoapi.DuoApi{ikey:%q, skey:%q, host:%q}", duoapi.ikey, Redacted, duoapi.host)
+}
+
+// String describes the credentials without their secret key.
+func (c Credentials) String() string {
+	return fmt.Sprintf("{IKey: %s, SKey: %s}", c.IKey, Redacted)
+}
+
+// GoString is like String, for the %#v verb.
+func (c Credentials) GoString() string {
+	return fmt.Sprintf("duoapi.Credentials{IKey:%q, SKey:%q}", c.IKey, Redacted)
+}
+
+// sensitiveFields are the parameters and JSON fields whose values are
+// redacted from dumps.
+var sensitiveFields = map[string]bool{
+	"skey":               true,
+	"secret_key":         true,
+	"password":           true,
+	"passcode":           true,
+	"activation_code":    true,
+	"activation_barcode": true,
+	"activation_url":     true,
+}
+
+// sensitiveResponsePaths are the API paths whose whole response is secret.
+var sensitiveResponsePaths = []string{"/bypass_codes"}
+
+// DumpRequest is like httputil.DumpRequestOut, but redacts the
+// Authorization header and any secrets among the request's parameters.
+// The request's body remains readable.
+func DumpRequest(req *http.Request) ([]byte, error) {
+	dump := req.Clone(req.Context())
+	for _, name := range []string{"Authorization", "Proxy-Authorization"} {
+		if dump.Header.Get(name) != "" {
+			dump.Header.Set(name, Redacted)
+		}
+	}
+	dump.URL.RawQuery = redactValues(dump.URL.Query()).Encode()
+
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := readRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+		body = redactBody(req.Header.Get("Content-Type"), body, false)
+		dump.Body = ioutil.NopCloser(bytes.NewReader(body))
+		dump.ContentLength = int64(len(body))
+	}
+	return httputil.DumpRequestOut(dump, true)
+}
+
+// readRequestBody returns the body of req, leaving it readable.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(body)
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, err
+}
+
+// DumpResponse is like httputil.DumpResponse for resp with the given body,
+// but redacts any secrets in the body.  A nil body, as from a streamed
+// call, dumps only the status line and headers.
+func DumpResponse(resp *http.Response, body []byte) ([]byte, error) {
+	dump := *resp
+	if body == nil {
+		dump.Body = http.NoBody
+		return httputil.DumpResponse(&dump, false)
+	}
+	secretResponse := false
+	if resp.Request != nil {
+		for _, path := range sensitiveResponsePaths {
+			if strings.HasSuffix(resp.Request.URL.Path, path) {
+				secretResponse = true
+			}
+		}
+	}
+	body = redactBody(resp.Header.Get("Content-Type"), body, secretResponse)
+	dump.Body = ioutil.NopCloser(bytes.NewReader(body))
+	dump.ContentLength = int64(len(body))
+	dump.TransferEncoding = nil
+	return httputil.DumpResponse(&dump, true)
+}
+
+// DumpMiddleware returns middleware which writes every attempt's request
+// and response to w, redacted by DumpRequest and DumpResponse, for
+// debugging.
+//
+// Example: duoapi.NewDuoApi(ikey,skey,host,userAgent,duoapi.SetMiddleware(duoapi.DumpMiddleware(os.Stderr)))
+func DumpMiddleware(w io.Writer) Middleware {
+	var mu sync.Mutex
+	write := func(dump []byte, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			fmt.Fprintf(w, "duoapi: dump failed: %v\n", err)
+			return
+		}
+		w.Write(dump)
+		fmt.Fprintln(w)
+	}
+	return func(next Handler) Handler {
+		return func(req *Request) (*http.Response, []byte, error) {
+			write(DumpRequest(req.HTTPRequest))
+			resp, body, err := next(req)
+			if resp != nil {
+				write(DumpResponse(resp, body))
+			}
+			return resp, body, err
+		}
+	}
+}
+
+func redactValues(values url.Values) url.Values {
+	for key := range values {
+		if sensitiveFields[key] {
+			values[key] = []string{Redacted}
+		}
+	}
+	return values
+}
+
+// redactBody redacts the secrets in a form or JSON encoded body.  If
+// secretResponse is set, the response field of a JSON body is redacted
+// whole.  Bodies in other formats, such as images, are returned unchanged.
+func redactBody(contentType string, body []byte, secretResponse bool) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/x-www-form-urlencoded" {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return []byte(Redacted)
+		}
+		return []byte(redactValues(values).Encode())
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return body
+	}
+	if object, ok := decoded.(map[string]interface{}); ok && secretResponse {
+		if _, ok := object["response"]; ok {
+			object["response"] = Redacted
+		}
+	}
+	redacted, err := json.Marshal(redactJSON(decoded))
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+func redactJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if sensitiveFields[key] {
+				v[key] = Redacted
+			} else {
+				v[key] = redactJSON(field)
+			}
+		}
+	case []interface{}:
+		for i, element := range v {
+			v[i] = redactJSON(element)
+		}
+	}
+	return value
+}
@@ -0,0 +1,25 @@
+//go:build go1.21
+// +build go1.21
+
+package duoapi
+
+import "log/slog"
+
+// LogValue implements slog.LogValuer, logging the DuoApi without its
+// secret key.
+func (duoapi DuoApi) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("ikey", duoapi.ikey),
+		slog.String("skey", Redacted),
+		slog.String("host", duoapi.host),
+	)
+}
+
+// LogValue implements slog.LogValuer, logging the credentials without
+// their secret key.
+func (c Credentials) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("ikey", c.IKey),
+		slog.String("skey", Redacted),
+	)
+}
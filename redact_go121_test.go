@@ -0,0 +1,28 @@
+//go:build go1.21
+// +build go1.21
+
+package duoapi
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogValueRedactsSecretKey(t *testing.T) {
+	duo := NewDuoApi("DIWJ8X6AEYOR5OMC6TQ1", testSKey, "api-test.duosecurity.com", "go-client")
+	creds := Credentials{IKey: "DIWJ8X6AEYOR5OMC6TQ1", SKey: testSKey}
+
+	var out bytes.Buffer
+	for _, handler := range []slog.Handler{slog.NewTextHandler(&out, nil), slog.NewJSONHandler(&out, nil)} {
+		logger := slog.New(handler)
+		logger.Info("client", "duo", duo, "value", *duo, "creds", creds)
+	}
+	if strings.Contains(out.String(), testSKey) {
+		t.Errorf("Secret key in log output:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "DIWJ8X6AEYOR5OMC6TQ1") {
+		t.Errorf("Expected the ikey in log output:\n%s", out.String())
+	}
+}
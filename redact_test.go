@@ -0,0 +1,121 @@
+package duoapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSKey = "s3cr3t-skey-0123456789abcdefghijklmnopqr"
+
+// formatted returns value formatted with every verb likely to be used in
+// logs.
+func formatted(value interface{}) []string {
+	return []string{
+		fmt.Sprint(value),
+		fmt.Sprintf("%v", value),
+		fmt.Sprintf("%+v", value),
+		fmt.Sprintf("%#v", value),
+		fmt.Sprintf("%s", value),
+	}
+}
+
+func TestFormatRedactsSecretKey(t *testing.T) {
+	duo := NewDuoApi("DIWJ8X6AEYOR5OMC6TQ1", testSKey, "api-test.duosecurity.com", "go-client")
+	withProvider := NewDuoApi("DIWJ8X6AEYOR5OMC6TQ1", "", "api-test.duosecurity.com", "go-client",
+		SetCredentialsProvider(StaticCredentials("DIWJ8X6AEYOR5OMC6TQ1", testSKey)))
+
+	path := filepath.Join(t.TempDir(), "creds.json")
+	ioutil.WriteFile(path, []byte(`{"ikey": "DIWJ8X6AEYOR5OMC6TQ1", "skey": "`+testSKey+`"}`), 0600)
+	fileProvider := FileCredentials(path)
+	if _, err := fileProvider.Credentials(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]interface{}{
+		"value":              *duo,
+		"pointer":            duo,
+		"provider":           withProvider,
+		"provider field":     struct{ api DuoApi }{*withProvider},
+		"slice":              []DuoApi{*duo},
+		"unexported field":   struct{ api DuoApi }{*duo},
+		"unexported pointer": struct{ api *DuoApi }{duo},
+		"file provider": struct{ api DuoApi }{*NewDuoApi("", "", "api-test.duosecurity.com", "go-client",
+			SetCredentialsProvider(fileProvider))},
+		"credentials":         Credentials{IKey: "DIWJ8X6AEYOR5OMC6TQ1", SKey: testSKey},
+		"credentials pointer": &Credentials{IKey: "DIWJ8X6AEYOR5OMC6TQ1", SKey: testSKey},
+	}
+	for name, value := range values {
+		for _, out := range formatted(value) {
+			if strings.Contains(out, testSKey) {
+				t.Errorf("%s: secret key in formatted output %q", name, out)
+			}
+		}
+	}
+
+	if out := duo.String(); !strings.Contains(out, "DIWJ8X6AEYOR5OMC6TQ1") || !strings.Contains(out, Redacted) {
+		t.Errorf("Expected the ikey and a redaction marker, but got %q", out)
+	}
+}
+
+func TestDumpMiddlewareRedacts(t *testing.T) {
+	var authorization string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		r.ParseForm()
+		if r.PostForm.Get("passcode") != "123456" {
+			t.Errorf("Expected the server to receive the passcode, but got %q", r.PostForm.Get("passcode"))
+		}
+		if strings.HasSuffix(r.URL.Path, "/bypass_codes") {
+			fmt.Fprint(w, `{"stat": "OK", "response": ["407176182", "016931781"]}`)
+			return
+		}
+		fmt.Fprint(w, `{"stat": "OK", "response": {"name": "Web SDK", "secret_key": "integration-secret"}}`)
+	}))
+	defer ts.Close()
+	base, _ := url.Parse(ts.URL)
+
+	var dump bytes.Buffer
+	duo := NewDuoApi("DIWJ8X6AEYOR5OMC6TQ1", testSKey, "api-test.duosecurity.com", "go-client",
+		SetBaseURL(base), SetMiddleware(DumpMiddleware(&dump)))
+	params := url.Values{"username": []string{"jsmith"}, "passcode": []string{"123456"}}
+	for _, uri := range []string{"/admin/v1/integrations/DIABC", "/admin/v1/users/DU123/bypass_codes"} {
+		if err := duo.SignedCallInto(context.Background(), "POST", uri, params, &StatResult{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	out := dump.String()
+	for _, leaked := range []string{testSKey, authorization, "123456", "integration-secret", "407176182"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("Dump contains %q:\n%s", leaked, out)
+		}
+	}
+	for _, kept := range []string{"POST /admin/v1/integrations/DIABC", "username=jsmith", "Web SDK", "Authorization: " + Redacted} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("Expected the dump to contain %q:\n%s", kept, out)
+		}
+	}
+}
+
+func TestDumpRequestQuery(t *testing.T) {
+	req := httptest.NewRequest("GET", "https://api-test.duosecurity.com/auth/v2/enroll_status?activation_code=abc123&user_id=DU1", nil)
+	req.Header.Set("Authorization", "Basic "+testSKey)
+	dump, err := DumpRequest(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(string(dump), "abc123") || strings.Contains(string(dump), testSKey) {
+		t.Errorf("Dump contains secrets:\n%s", dump)
+	}
+	if !strings.Contains(string(dump), "user_id=DU1") {
+		t.Errorf("Expected the dump to keep other parameters:\n%s", dump)
+	}
+}
@@ -0,0 +1,202 @@
+package duoapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy decides whether a Duo API call is retried after an attempt,
+// and how long to wait before the next one.
+type RetryPolicy interface {
+	// Retry is consulted after every attempt.  attempt is the number of
+	// attempts made so far and elapsed the time since the first one started.
+	// Exactly one of resp and err is non-nil.  Retry returns the delay before
+	// the next attempt, or false to return the attempt's result to the caller.
+	Retry(attempt int, elapsed time.Duration, resp *http.Response, err error) (time.Duration, bool)
+}
+
+// BackoffPolicy is a RetryPolicy which waits exponentially longer between
+// attempts.
+type BackoffPolicy struct {
+	// InitialBackoff is the delay before the first retry.  Each later delay
+	// is Factor times the one before.
+	InitialBackoff time.Duration
+	Factor         float64
+	// MaxBackoff is the longest delay; a call is not retried again once the
+	// delay it would wait next, computed or from Retry-After, exceeds it.
+	MaxBackoff time.Duration
+	// MaxElapsed, if non-zero, caps the total time spent on a call, so no
+	// retry is made whose delay would run past it.
+	MaxElapsed time.Duration
+	// RetryStatusCodes are the HTTP status codes which are retried.
+	RetryStatusCodes []int
+	// RetryNetworkErrors retries attempts which failed with a transient
+	// network error.  Failures to connect, such as a refused connection, are
+	// retried for every method.  Errors which may have come after the
+	// request was sent, such as a reset connection, are only retried for
+	// idempotent methods, so that e.g. a push is never sent twice.
+	RetryNetworkErrors bool
+	// RespectRetryAfter waits for the delay in a response's Retry-After
+	// header, when present, instead of the computed backoff, whenever it
+	// fits within MaxBackoff and MaxElapsed.  The server then sets the
+	// pace, so set MaxElapsed to bound how long such calls are retried.
+	RespectRetryAfter bool
+}
+
+// DefaultRetryPolicy returns the policy used unless SetRetryPolicy is given:
+// rate limited (HTTP 429) calls are retried after 1 second, doubling the
+// delay each time up to 32 seconds.
+func DefaultRetryPolicy() *BackoffPolicy {
+	return &BackoffPolicy{
+		InitialBackoff:   initialBackoffMS * time.Millisecond,
+		Factor:           backoffFactor,
+		MaxBackoff:       maxBackoffMS * time.Millisecond,
+		RetryStatusCodes: []int{rateLimitHttpCode},
+	}
+}
+
+// TransientRetryPolicy returns a policy which, in addition to rate limited
+// calls, retries transient network errors and HTTP 502, 503 and 504
+// responses.  It honors Retry-After and gives up after two minutes.
+func TransientRetryPolicy() *BackoffPolicy {
+	policy := DefaultRetryPolicy()
+	policy.RetryStatusCodes = append(policy.RetryStatusCodes,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout)
+	policy.RetryNetworkErrors = true
+	policy.RespectRetryAfter = true
+	policy.MaxElapsed = 2 * time.Minute
+	return policy
+}
+
+// Retry implements RetryPolicy.
+func (p *BackoffPolicy) Retry(attempt int, elapsed time.Duration, resp *http.Response, err error) (time.Duration, bool) {
+	if err != nil {
+		if !p.RetryNetworkErrors || !isTransientNetworkError(err) {
+			return 0, false
+		}
+	} else if !p.retriesStatus(resp.StatusCode) {
+		return 0, false
+	}
+
+	delay := time.Duration(float64(p.InitialBackoff) * math.Pow(p.Factor, float64(attempt-1)))
+	if p.RespectRetryAfter && resp != nil {
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			delay = retryAfter
+		}
+	}
+	if delay > p.MaxBackoff || delay < 0 {
+		return 0, false
+	}
+	if p.MaxElapsed > 0 && elapsed+delay > p.MaxElapsed {
+		return 0, false
+	}
+	return delay, true
+}
+
+func (p *BackoffPolicy) retriesStatus(status int) bool {
+	for _, code := range p.RetryStatusCodes {
+		if code == status {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or as
+// an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// idempotentMethods are the methods which may safely be sent twice, as
+// http.Client names them in a *url.Error.
+var idempotentMethods = map[string]bool{
+	"Get":     true,
+	"Head":    true,
+	"Put":     true,
+	"Delete":  true,
+	"Options": true,
+}
+
+// isTransientNetworkError reports whether err, returned from an HTTP round
+// trip, is worth retrying.  Cancellation and TLS verification failures
+// are not.  Failures to connect always are, but other errors, which may
+// have come after the request was sent, are only retried for idempotent
+// methods.
+func isTransientNetworkError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if isConnectError(err) {
+		return true
+	}
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) || !idempotentMethods[urlErr.Op] {
+		return false
+	}
+	if errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isConnectError reports whether err is a failure to connect, before any
+// of the request could be sent.
+func isConnectError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && (opErr.Op == "dial" || opErr.Op == "proxyconnect") {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// SetRetryPolicy is an optional parameter for NewDuoApi which replaces
+// DefaultRetryPolicy as the policy deciding which calls are retried.
+func SetRetryPolicy(policy RetryPolicy) func(*apiOptions) {
+	return func(opts *apiOptions) {
+		opts.retryPolicy = policy
+	}
+}
+
+// ReportAttempts is an optional parameter for the Call functions which stores
+// the number of attempts made, including the first, in attempts.
+func ReportAttempts(attempts *int) DuoApiOption {
+	return func(opts *requestOptions) {
+		opts.attempts = attempts
+	}
+}
@@ -0,0 +1,199 @@
+package duoapi
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDefaultRetryPolicy(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	resp := &http.Response{StatusCode: 429, Header: http.Header{}}
+	for attempt, expected := range completeRateLimitSleepDurations {
+		delay, retry := policy.Retry(attempt+1, 0, resp, nil)
+		if !retry {
+			t.Fatalf("Attempt %d was not retried", attempt+1)
+		}
+		if delay != expected {
+			t.Fatalf("Attempt %d waited %v instead of %v", attempt+1, delay, expected)
+		}
+	}
+	if _, retry := policy.Retry(len(completeRateLimitSleepDurations)+1, 0, resp, nil); retry {
+		t.Fatal("Retried after the maximum backoff")
+	}
+
+	if _, retry := policy.Retry(1, 0, &http.Response{StatusCode: 503}, nil); retry {
+		t.Error("Default policy retried a 503")
+	}
+	if _, retry := policy.Retry(1, 0, nil, syscall.ECONNRESET); retry {
+		t.Error("Default policy retried a network error")
+	}
+}
+
+func TestTransientRetryPolicy(t *testing.T) {
+	policy := TransientRetryPolicy()
+
+	for _, status := range []int{429, 502, 503, 504} {
+		if _, retry := policy.Retry(1, 0, &http.Response{StatusCode: status}, nil); !retry {
+			t.Errorf("HTTP %d was not retried", status)
+		}
+	}
+	if _, retry := policy.Retry(1, 0, &http.Response{StatusCode: 500}, nil); retry {
+		t.Error("HTTP 500 was retried")
+	}
+
+	transient := []error{
+		&url.Error{Op: "Get", URL: "https://example.com", Err: syscall.ECONNRESET},
+		&url.Error{Op: "Post", URL: "https://example.com", Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}},
+		&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED},
+	}
+	for _, err := range transient {
+		if _, retry := policy.Retry(1, 0, nil, err); !retry {
+			t.Errorf("Transient error %v was not retried", err)
+		}
+	}
+	notSent := []error{
+		&url.Error{Op: "Post", URL: "https://example.com", Err: syscall.ECONNRESET},
+		&url.Error{Op: "Post", URL: "https://example.com", Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}},
+		syscall.ECONNRESET,
+	}
+	for _, err := range notSent {
+		if _, retry := policy.Retry(1, 0, nil, err); retry {
+			t.Errorf("Error %v, which may have come after a POST was sent, was retried", err)
+		}
+	}
+	if _, retry := policy.Retry(1, 0, nil, context.Canceled); retry {
+		t.Error("Canceled call was retried")
+	}
+}
+
+func TestBackoffPolicyRetryAfter(t *testing.T) {
+	policy := TransientRetryPolicy()
+	resp := &http.Response{StatusCode: 503, Header: http.Header{}}
+
+	resp.Header.Set("Retry-After", "7")
+	if delay, retry := policy.Retry(1, 0, resp, nil); !retry || delay != 7*time.Second {
+		t.Errorf("Expected to wait 7s from Retry-After, got %v (retry %v)", delay, retry)
+	}
+
+	// A short Retry-After is honored even once the computed backoff, 64s
+	// for the seventh attempt, is past MaxBackoff, but not past MaxElapsed.
+	if delay, retry := policy.Retry(7, 0, resp, nil); !retry || delay != 7*time.Second {
+		t.Errorf("Expected to wait 7s from Retry-After on attempt 7, got %v (retry %v)", delay, retry)
+	}
+	if delay, retry := policy.Retry(7, policy.MaxElapsed-5*time.Second, resp, nil); retry {
+		t.Errorf("Expected no retry for a Retry-After past MaxElapsed, got %v", delay)
+	}
+	resp.Header.Del("Retry-After")
+	if delay, retry := policy.Retry(7, 0, resp, nil); retry {
+		t.Errorf("Expected no retry for a computed backoff past MaxBackoff, got %v", delay)
+	}
+
+	resp.Header.Set("Retry-After", "86400")
+	if delay, retry := policy.Retry(1, 0, resp, nil); retry {
+		t.Errorf("Expected no retry for a Retry-After past MaxBackoff, got %v", delay)
+	}
+
+	resp.Header.Set("Retry-After", "garbage")
+	if delay, retry := policy.Retry(1, 0, resp, nil); !retry || delay != time.Second {
+		t.Errorf("Expected to fall back to 1s backoff, got %v (retry %v)", delay, retry)
+	}
+
+	resp.Header.Set("Retry-After", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	if delay, retry := policy.Retry(1, 0, resp, nil); !retry || delay != 0 {
+		t.Errorf("Expected no wait for a past Retry-After date, got %v (retry %v)", delay, retry)
+	}
+}
+
+func TestBackoffPolicyMaxElapsed(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	policy.MaxElapsed = 10 * time.Second
+	resp := &http.Response{StatusCode: 429}
+
+	if _, retry := policy.Retry(2, 7*time.Second, resp, nil); !retry {
+		t.Error("Retry within the elapsed cap was refused")
+	}
+	if _, retry := policy.Retry(3, 7*time.Second, resp, nil); retry {
+		t.Error("Retry past the elapsed cap was allowed")
+	}
+}
+
+type flakyHttpClient struct {
+	errs      []error
+	responses []http.Response
+	calls     int
+}
+
+func (c *flakyHttpClient) Do(req *http.Request) (*http.Response, error) {
+	c.calls++
+	if len(c.errs) > 0 {
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		return nil, err
+	}
+	resp := c.responses[0]
+	c.responses = c.responses[1:]
+	return &resp, nil
+}
+
+func TestCallRetriesNetworkErrors(t *testing.T) {
+	httpClient := &flakyHttpClient{
+		errs: []error{&url.Error{Op: "Get", URL: "https://host.baz", Err: syscall.ECONNRESET}},
+		responses: []http.Response{{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte("hello world"))),
+		}},
+	}
+	sleepSvc := &mockSleepService{}
+	duo := &DuoApi{
+		ikey:        "ikey-foo",
+		skey:        newSecret("skey-bar"),
+		host:        "host.baz",
+		userAgent:   "ua-qux",
+		apiClient:   httpClient,
+		authClient:  httpClient,
+		sleepSvc:    sleepSvc,
+		retryPolicy: TransientRetryPolicy(),
+	}
+
+	attempts := 0
+	resp, body, err := duo.SignedCall("GET", "/v9/hello/world", url.Values{}, ReportAttempts(&attempts))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.StatusCode != 200 || string(body) != "hello world" {
+		t.Fatalf("Unexpected response: %d %q", resp.StatusCode, body)
+	}
+	if httpClient.calls != 2 || attempts != 2 {
+		t.Fatalf("Expected 2 attempts, made %d and reported %d", httpClient.calls, attempts)
+	}
+	if len(sleepSvc.sleepCalls) != 1 || sleepSvc.sleepCalls[0] != time.Second {
+		t.Fatalf("Unexpected sleeps: %v", sleepSvc.sleepCalls)
+	}
+}
+
+func TestReportAttemptsRateLimited(t *testing.T) {
+	duo, _, _ := getMockClients([]http.Response{rateLimitResp, rateLimitResp, okResp})
+	attempts := 0
+	_, _, err := duo.Call("GET", "/v9/hello/world", url.Values{}, ReportAttempts(&attempts))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("Expected 3 attempts, but got %d", attempts)
+	}
+}
+
+func TestSetRetryPolicy(t *testing.T) {
+	duo := NewDuoApi("ABC", "123", "api-XXXXXXX.duosecurity.com", "go-client", SetRetryPolicy(TransientRetryPolicy()))
+	policy, ok := duo.retryPolicy.(*BackoffPolicy)
+	if !ok || !policy.RetryNetworkErrors {
+		t.Fatal("SetRetryPolicy failed to configure the retry policy")
+	}
+}
@@ -0,0 +1,109 @@
+package duoapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// ErrResponseTooLarge is returned, wrapped with the call's method and URI,
+// when a response body exceeds the limit set with SetMaxResponseSize.
+var ErrResponseTooLarge = errors.New("duoapi: response body too large")
+
+// SetMaxResponseSize is an optional parameter for NewDuoApi which fails any
+// call whose response body is longer than limit bytes with
+// ErrResponseTooLarge, instead of reading it all into memory.  Streamed
+// responses fail when the limit is reached while reading them.  By default
+// there is no limit.
+func SetMaxResponseSize(limit int64) func(*apiOptions) {
+	return func(opts *apiOptions) {
+		opts.maxResponseSize = limit
+	}
+}
+
+// StreamResponse is passed to a call to stream its response: the call
+// returns a nil body and leaves resp.Body, which the caller must close, for
+// reading, e.g. by a json.Decoder.  CallInto, SignedCallInto and
+// JSONSignedCallInto given StreamResponse decode the response as it is
+// read, without holding the whole body in memory.
+//
+// Example: resp, _, err := duo.SignedCall("GET", "/admin/v2/logs/authentication", params, duoapi.StreamResponse)
+func StreamResponse(opts *requestOptions) {
+	opts.stream = true
+}
+
+// limitedBody fails reads with err once more than remaining bytes have been
+// read from the response body it wraps.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	err       error
+	exceeded  bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, b.err
+	}
+	// Read one byte past the limit to tell a body of exactly the limit from
+	// a longer one.
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		b.exceeded = true
+		return int(b.remaining), b.err
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// limitBody wraps resp.Body to enforce limit, if it is positive, returning
+// an error straight away if the response's Content-Length exceeds it.
+func limitBody(req *Request, resp *http.Response, limit int64) error {
+	if limit <= 0 {
+		return nil
+	}
+	err := fmt.Errorf("%w: %s %s exceeded %d bytes", ErrResponseTooLarge, req.Method, req.URI, limit)
+	if resp.ContentLength > limit {
+		return err
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: limit, err: err}
+	return nil
+}
+
+// statResulter is implemented by results which embed StatResult.
+type statResulter interface {
+	statResult() *StatResult
+}
+
+func (result *StatResult) statResult() *StatResult {
+	return result
+}
+
+// decodeStream decodes the streamed response to a call to uri into result,
+// closing its body.  Like decodeResult, it returns an *Error for a 2xx
+// response whose stat is "FAIL", if result embeds StatResult.
+func decodeStream(uri string, resp *http.Response, result interface{}) error {
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		return decodeResult(uri, resp, body, result)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return err
+	}
+	if r, ok := result.(statResulter); ok && r.statResult().Stat == "FAIL" {
+		e := newError(resp, r.statResult())
+		e.Path = uri
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package duoapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// closeTrackingBody is a response body which records whether it was closed.
+type closeTrackingBody struct {
+	*strings.Reader
+	closed bool
+}
+
+func (b *closeTrackingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	body := `{"stat": "OK", "response": "` + strings.Repeat("x", 100) + `"}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("chunked") == "" {
+			w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+			fmt.Fprint(w, body)
+			return
+		}
+		// Flushing first leaves the length unknown to the client.
+		fmt.Fprint(w, body[:10])
+		w.(http.Flusher).Flush()
+		fmt.Fprint(w, body[10:])
+	}))
+	defer ts.Close()
+	base, _ := url.Parse(ts.URL)
+
+	for _, chunked := range []string{"", "1"} {
+		params := url.Values{}
+		if chunked != "" {
+			params.Set("chunked", chunked)
+		}
+
+		duo := NewDuoApi("ABC", "123", "api-test.duosecurity.com", "go-client",
+			SetBaseURL(base), SetMaxResponseSize(int64(len(body))))
+		if _, got, err := duo.SignedCall("GET", "/auth/v2/check", params); err != nil || string(got) != body {
+			t.Fatalf("chunked=%q: a body of exactly the limit failed: %q, %v", chunked, got, err)
+		}
+
+		duo = NewDuoApi("ABC", "123", "api-test.duosecurity.com", "go-client",
+			SetBaseURL(base), SetMaxResponseSize(int64(len(body)-1)))
+		_, _, err := duo.SignedCall("GET", "/auth/v2/check", params)
+		if !errors.Is(err, ErrResponseTooLarge) {
+			t.Fatalf("chunked=%q: expected ErrResponseTooLarge, but got %v", chunked, err)
+		}
+		if !strings.Contains(err.Error(), "GET /auth/v2/check") {
+			t.Errorf("chunked=%q: expected the error to name the call, but got %v", chunked, err)
+		}
+
+		var result StatResult
+		err = duo.SignedCallInto(context.Background(), "GET", "/auth/v2/check", params, &result, StreamResponse)
+		if !errors.Is(err, ErrResponseTooLarge) {
+			t.Fatalf("chunked=%q: expected a streamed ErrResponseTooLarge, but got %v", chunked, err)
+		}
+	}
+}
+
+func TestStreamResponse(t *testing.T) {
+	body := &closeTrackingBody{Reader: strings.NewReader(`{"stat": "OK", "response": ["a", "b"]}`)}
+	duo, _, _ := getMockClients([]http.Response{{StatusCode: 200, Body: body}})
+
+	resp, got, err := duo.SignedCall("GET", "/admin/v1/users", nil, StreamResponse)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got != nil {
+		t.Errorf("Expected no buffered body, but got %q", got)
+	}
+	if body.closed {
+		t.Fatal("Expected the streamed body to be left open")
+	}
+	read, _ := ioutil.ReadAll(resp.Body)
+	if !strings.Contains(string(read), `"response": ["a", "b"]`) {
+		t.Errorf("Unexpected streamed body %q", read)
+	}
+}
+
+func TestStreamResponseInto(t *testing.T) {
+	okBody := &closeTrackingBody{Reader: strings.NewReader(`{"stat": "OK", "response": ["a", "b"]}`)}
+	failBody := &closeTrackingBody{Reader: strings.NewReader(`{"stat": "FAIL", "code": 40401, "message": "Resource not found"}`)}
+	duo, _, _ := getMockClients([]http.Response{
+		{StatusCode: 200, Body: okBody},
+		{StatusCode: 404, Body: failBody},
+		{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(`{"stat": "FAIL", "code": 40002, "message": "Invalid request parameters"}`))},
+	})
+
+	result := struct {
+		StatResult
+		Response []string
+	}{}
+	if err := duo.SignedCallInto(context.Background(), "GET", "/admin/v1/users", nil, &result, StreamResponse); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Response) != 2 || !okBody.closed {
+		t.Errorf("Unexpected result %+v, or the body was not closed", result)
+	}
+
+	err := duo.SignedCallInto(context.Background(), "GET", "/admin/v1/users/DU123", nil, &result, StreamResponse)
+	if !IsNotFound(err) || err.(*Error).Path != "/admin/v1/users/DU123" {
+		t.Errorf("Expected a not found *Error, but got %v", err)
+	}
+	if !failBody.closed {
+		t.Error("Expected the error body to be closed")
+	}
+
+	result.StatResult = StatResult{}
+	err = duo.SignedCallInto(context.Background(), "GET", "/admin/v1/users", nil, &result, StreamResponse)
+	if !IsInvalidParams(err) || err.(*Error).Path != "/admin/v1/users" {
+		t.Errorf("Expected an invalid parameters *Error for a FAIL body with HTTP 200, but got %v", err)
+	}
+}
+
+func TestStreamResponseClosesRetriedBodies(t *testing.T) {
+	limited := &closeTrackingBody{Reader: strings.NewReader(`{"stat": "FAIL", "code": 42901}`)}
+	ok := &closeTrackingBody{Reader: strings.NewReader(`{"stat": "OK"}`)}
+	duo, _, _ := getMockClients([]http.Response{
+		{StatusCode: 429, Body: limited},
+		{StatusCode: 200, Body: ok},
+	})
+
+	resp, _, err := duo.SignedCall("GET", "/admin/v1/users", nil, StreamResponse)
+	if err != nil || resp.StatusCode != 200 {
+		t.Fatalf("Unexpected result %v, %v", resp, err)
+	}
+	if !limited.closed {
+		t.Error("Expected the rate limited attempt's body to be closed")
+	}
+	if ok.closed {
+		t.Error("Expected the returned body to be left open")
+	}
+}
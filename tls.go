@@ -0,0 +1,164 @@
+package duoapi
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"time"
+)
+
+// ErrPinMismatch is returned when a server's certificate chain contains no
+// key pinned with SetPinnedSPKI.
+var ErrPinMismatch = errors.New("duoapi: no certificate in the server's chain matches a pinned key")
+
+// SetCertPool is an optional parameter for NewDuoApi which verifies Duo's
+// certificate against pool instead of the embedded bundle of roots, e.g.
+// to trust a TLS-inspecting proxy.
+func SetCertPool(pool *x509.CertPool) func(*apiOptions) {
+	return func(opts *apiOptions) {
+		opts.certPool = pool
+	}
+}
+
+// AppendSystemRoots is an optional parameter for NewDuoApi which trusts the
+// system's roots in addition to the embedded bundle.  It has no effect with
+// SetCertPool; build that pool from x509.SystemCertPool instead.
+func AppendSystemRoots() func(*apiOptions) {
+	return func(opts *apiOptions) {
+		opts.appendSystemRoots = true
+	}
+}
+
+// UseSystemRoots is an optional parameter for NewDuoApi which trusts only
+// the system's roots, instead of the embedded bundle.
+func UseSystemRoots() func(*apiOptions) {
+	return func(opts *apiOptions) {
+		opts.useSystemRoots = true
+	}
+}
+
+// SetPinnedSPKI is an optional parameter for NewDuoApi which additionally
+// requires some certificate in the server's chain to have one of the given
+// public keys.  Each pin is the base64 encoded SHA-256 hash of a
+// certificate's DER encoded SubjectPublicKeyInfo, as used by HPKP.  With
+// SetInsecure, a pinned CA only matches a chain which verifies against the
+// roots; otherwise only the server's own certificate can match.
+func SetPinnedSPKI(pins ...string) func(*apiOptions) {
+	return func(opts *apiOptions) {
+		opts.pins = append(opts.pins, pins...)
+	}
+}
+
+// SPKIHash returns the pin for cert accepted by SetPinnedSPKI.
+func SPKIHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// PinnedCert describes one of the root certificates embedded in this
+// package.
+type PinnedCert struct {
+	Subject  string
+	NotAfter time.Time
+}
+
+// PinnedCertExpirations reports when each of the embedded root
+// certificates expires, so that deployments can be warned before one does.
+func PinnedCertExpirations() ([]PinnedCert, error) {
+	certs := []PinnedCert{}
+	rest := []byte(duoPinnedCert)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, PinnedCert{
+			Subject:  cert.Subject.String(),
+			NotAfter: cert.NotAfter,
+		})
+	}
+	return certs, nil
+}
+
+func buildTLSConfig(opts *apiOptions) *tls.Config {
+	config := &tls.Config{
+		InsecureSkipVerify: opts.insecure,
+	}
+
+	switch {
+	case opts.certPool != nil:
+		config.RootCAs = opts.certPool
+	case opts.useSystemRoots:
+		// A nil pool verifies against the system's roots.
+		config.RootCAs = nil
+	default:
+		// Certificate pinning
+		certPool := x509.NewCertPool()
+		if opts.appendSystemRoots {
+			if systemPool, err := x509.SystemCertPool(); err == nil {
+				certPool = systemPool
+			}
+		}
+		certPool.AppendCertsFromPEM([]byte(duoPinnedCert))
+		config.RootCAs = certPool
+	}
+
+	if len(opts.pins) > 0 {
+		config.VerifyPeerCertificate = verifyPins(opts.pins, config.RootCAs)
+	}
+	return config
+}
+
+// verifyPins returns a tls.Config.VerifyPeerCertificate function which
+// requires a pinned key in the verified chains.  When verification is
+// skipped, the presented chain is verified against roots here, without
+// checking the host name, so that a pinned CA certificate appended to a
+// forged chain doesn't match; if that fails, only the leaf, whose key the
+// server has proven it holds, may match.
+func verifyPins(pins []string, roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	pinned := make(map[string]bool)
+	for _, pin := range pins {
+		pinned[pin] = true
+	}
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if len(verifiedChains) == 0 && len(rawCerts) > 0 {
+			certs := make([]*x509.Certificate, 0, len(rawCerts))
+			for _, raw := range rawCerts {
+				cert, err := x509.ParseCertificate(raw)
+				if err != nil {
+					return err
+				}
+				certs = append(certs, cert)
+			}
+			intermediates := x509.NewCertPool()
+			for _, cert := range certs[1:] {
+				intermediates.AddCert(cert)
+			}
+			chains, err := certs[0].Verify(x509.VerifyOptions{
+				Roots:         roots,
+				Intermediates: intermediates,
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			})
+			if err != nil {
+				chains = [][]*x509.Certificate{certs[:1]}
+			}
+			verifiedChains = chains
+		}
+		for _, chain := range verifiedChains {
+			for _, cert := range chain {
+				if pinned[SPKIHash(cert)] {
+					return nil
+				}
+			}
+		}
+		return ErrPinMismatch
+	}
+}
@@ -0,0 +1,175 @@
+package duoapi
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTLSTestServer() *httptest.Server {
+	return httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, `{"stat": "OK"}`)
+		}),
+	)
+}
+
+func tlsTestCall(ts *httptest.Server, options ...func(*apiOptions)) error {
+	host := strings.TrimPrefix(ts.URL, "https://")
+	duo := NewDuoApi("ABC", "123", host, "go-client", options...)
+	_, _, err := duo.Call("GET", "/auth/v2/ping", url.Values{})
+	return err
+}
+
+func TestSetCertPool(t *testing.T) {
+	ts := newTLSTestServer()
+	defer ts.Close()
+
+	if err := tlsTestCall(ts); err == nil {
+		t.Fatal("Test server was trusted by the embedded roots")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+	if err := tlsTestCall(ts, SetCertPool(pool)); err != nil {
+		t.Fatalf("Unexpected error with a custom pool: %v", err)
+	}
+}
+
+func TestSetPinnedSPKI(t *testing.T) {
+	ts := newTLSTestServer()
+	defer ts.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+
+	pin := SPKIHash(ts.Certificate())
+	if err := tlsTestCall(ts, SetCertPool(pool), SetPinnedSPKI("bm90IHRoZSBwaW4=", pin)); err != nil {
+		t.Fatalf("Unexpected error with a matching pin: %v", err)
+	}
+
+	err := tlsTestCall(ts, SetCertPool(pool), SetPinnedSPKI("bm90IHRoZSBwaW4="))
+	if !errors.Is(err, ErrPinMismatch) {
+		t.Fatalf("Expected ErrPinMismatch, but got %v", err)
+	}
+
+	err = tlsTestCall(ts, SetInsecure(), SetPinnedSPKI("bm90IHRoZSBwaW4="))
+	if !errors.Is(err, ErrPinMismatch) {
+		t.Fatalf("Expected ErrPinMismatch without verification, but got %v", err)
+	}
+}
+
+// newTestCert returns a certificate for 127.0.0.1, or a CA if isCA, signed
+// by parent and parentKey, or self-signed if parent is nil.
+func newTestCert(t *testing.T, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+// newChainTestServer returns a TLS server which presents chain, whose first
+// certificate has key.
+func newChainTestServer(key *ecdsa.PrivateKey, chain ...*x509.Certificate) *httptest.Server {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"stat": "OK"}`)
+	}))
+	cert := tls.Certificate{PrivateKey: key}
+	for _, c := range chain {
+		cert.Certificate = append(cert.Certificate, c.Raw)
+	}
+	ts.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	ts.StartTLS()
+	return ts
+}
+
+func TestSetPinnedSPKIInsecureChain(t *testing.T) {
+	ca, caKey := newTestCert(t, true, nil, nil)
+	leaf, leafKey := newTestCert(t, false, ca, caKey)
+	forged, forgedKey := newTestCert(t, false, nil, nil)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	ts := newChainTestServer(leafKey, leaf, ca)
+	err := tlsTestCall(ts, SetInsecure(), SetCertPool(pool), SetPinnedSPKI(SPKIHash(ca)))
+	ts.Close()
+	if err != nil {
+		t.Fatalf("Unexpected error with a pinned CA: %v", err)
+	}
+
+	ts = newChainTestServer(forgedKey, forged, ca)
+	defer ts.Close()
+	err = tlsTestCall(ts, SetInsecure(), SetCertPool(pool), SetPinnedSPKI(SPKIHash(ca)))
+	if !errors.Is(err, ErrPinMismatch) {
+		t.Fatalf("Expected ErrPinMismatch for a forged leaf, but got %v", err)
+	}
+	if err := tlsTestCall(ts, SetInsecure(), SetPinnedSPKI(SPKIHash(forged))); err != nil {
+		t.Fatalf("Unexpected error with a pinned leaf: %v", err)
+	}
+}
+
+func tlsConfigOf(duo *DuoApi) *tls.Config {
+	return duo.apiClient.(*http.Client).Transport.(*http.Transport).TLSClientConfig
+}
+
+func TestSystemRoots(t *testing.T) {
+	duo := NewDuoApi("ABC", "123", "api-XXXXXXX.duosecurity.com", "go-client", UseSystemRoots())
+	if tlsConfigOf(duo).RootCAs != nil {
+		t.Error("UseSystemRoots kept a custom root pool")
+	}
+
+	duo = NewDuoApi("ABC", "123", "api-XXXXXXX.duosecurity.com", "go-client")
+	embedded := len(tlsConfigOf(duo).RootCAs.Subjects())
+	duo = NewDuoApi("ABC", "123", "api-XXXXXXX.duosecurity.com", "go-client", AppendSystemRoots())
+	if _, err := x509.SystemCertPool(); err == nil && len(tlsConfigOf(duo).RootCAs.Subjects()) < embedded {
+		t.Error("AppendSystemRoots dropped embedded roots")
+	}
+}
+
+func TestPinnedCertExpirations(t *testing.T) {
+	certs, err := PinnedCertExpirations()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(certs) != strings.Count(duoPinnedCert, "BEGIN CERTIFICATE") {
+		t.Fatalf("Expected every embedded root, but got %d", len(certs))
+	}
+	for _, cert := range certs {
+		if cert.Subject == "" || cert.NotAfter.Before(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Unexpected pinned cert %+v", cert)
+		}
+	}
+}
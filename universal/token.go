@@ -0,0 +1,130 @@
+package universal
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/duosecurity/duo_api_golang/internal/jwt"
+)
+
+// TokenClaims are the claims of a validated id_token: the result of the
+// user's authentication and its context.
+type TokenClaims struct {
+	Issuer            string      `json:"iss"`
+	Subject           string      `json:"sub"`
+	Audience          audience    `json:"aud"`
+	ExpiresAt         int64       `json:"exp"`
+	IssuedAt          int64       `json:"iat"`
+	Nonce             string      `json:"nonce"`
+	PreferredUsername string      `json:"preferred_username"`
+	AuthTime          int64       `json:"auth_time"`
+	AuthResult        AuthResult  `json:"auth_result"`
+	AuthContext       AuthContext `json:"auth_context"`
+}
+
+// AuthResult is the outcome of an authentication.  Result is "allow" when
+// the user was authenticated.
+type AuthResult struct {
+	Result    string `json:"result"`
+	Status    string `json:"status"`
+	StatusMsg string `json:"status_msg"`
+}
+
+// AuthContext describes an authentication, as in the Admin API's
+// authentication logs.
+type AuthContext struct {
+	TxID         string `json:"txid"`
+	EventType    string `json:"event_type"`
+	Factor       string `json:"factor"`
+	Reason       string `json:"reason"`
+	Result       string `json:"result"`
+	Timestamp    int64  `json:"timestamp"`
+	Isotimestamp string `json:"isotimestamp"`
+	Alias        string `json:"alias"`
+	Email        string `json:"email"`
+	Application  struct {
+		Key  string `json:"key"`
+		Name string `json:"name"`
+	} `json:"application"`
+	User struct {
+		Key    string   `json:"key"`
+		Name   string   `json:"name"`
+		Groups []string `json:"groups"`
+	} `json:"user"`
+	AccessDevice struct {
+		Browser  string   `json:"browser"`
+		Hostname string   `json:"hostname"`
+		IP       string   `json:"ip"`
+		OS       string   `json:"os"`
+		Location Location `json:"location"`
+	} `json:"access_device"`
+	AuthDevice struct {
+		IP       string   `json:"ip"`
+		Name     string   `json:"name"`
+		Location Location `json:"location"`
+	} `json:"auth_device"`
+}
+
+// Location is where a device was, as far as Duo could tell.
+type Location struct {
+	City    string `json:"city"`
+	State   string `json:"state"`
+	Country string `json:"country"`
+}
+
+// audience is a JWT aud claim, which is either a string or a list of them.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a audience) contains(value string) bool {
+	for _, v := range a {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func invalidIDToken(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidIDToken, fmt.Sprintf(format, args...))
+}
+
+// validateIDToken verifies token and returns its claims if it was issued to
+// this client for username, and carries nonce if that is not empty.
+func (c *Client) validateIDToken(token string, username string, nonce string) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+	if err := jwt.Verify(token, *c.clientSecret, claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	now := c.now()
+	switch {
+	case claims.Issuer != c.endpoint(tokenURI):
+		return nil, invalidIDToken("unexpected issuer %q", claims.Issuer)
+	case !claims.Audience.contains(c.clientID):
+		return nil, invalidIDToken("not issued to this client")
+	case now.Add(-leeway).After(time.Unix(claims.ExpiresAt, 0)):
+		return nil, invalidIDToken("expired at %v", time.Unix(claims.ExpiresAt, 0))
+	case now.Add(leeway).Before(time.Unix(claims.IssuedAt, 0)):
+		return nil, invalidIDToken("issued in the future at %v", time.Unix(claims.IssuedAt, 0))
+	case claims.PreferredUsername != username:
+		return nil, invalidIDToken("issued for %q instead of %q", claims.PreferredUsername, username)
+	case nonce != "" && claims.Nonce != nonce:
+		return nil, invalidIDToken("nonce does not match")
+	}
+	return claims, nil
+}
@@ -0,0 +1,309 @@
+// Package universal is a client for Duo's Universal Prompt, the OpenID
+// Connect flow which authenticates a web application's users in a frame
+// served by Duo.
+//
+// A login runs in three steps: HealthCheck confirms that Duo can be
+// reached, CreateAuthURL returns the URL to redirect the user's browser to,
+// and when Duo redirects the browser back with a code,
+// ExchangeAuthorizationCodeFor2faResult validates the id_token holding the
+// user's result, and fails with ErrDenied unless Duo allowed the login.
+// See https://duo.com/docs/oauthapi
+package universal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	duoapi "github.com/duosecurity/duo_api_golang"
+	"github.com/duosecurity/duo_api_golang/internal/jwt"
+)
+
+const (
+	clientIDLength     = 20
+	clientSecretLength = 40
+	minStateLength     = 22
+	maxStateLength     = 1024
+	// DefaultStateLength is the length of the states GenerateState returns.
+	DefaultStateLength = 36
+
+	// expiry is how long the JWTs the client signs are valid for.
+	expiry = 5 * time.Minute
+	// leeway is how far the times in an id_token may be off from the local
+	// clock.
+	leeway = time.Minute
+
+	healthCheckURI = "/oauth/v1/health_check"
+	authorizeURI   = "/oauth/v1/authorize"
+	tokenURI       = "/oauth/v1/token"
+
+	clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+)
+
+var (
+	// ErrInvalidClientID and ErrInvalidClientSecret are returned by
+	// NewClient for keys of the wrong length.
+	ErrInvalidClientID     = errors.New("universal: the client ID is invalid")
+	ErrInvalidClientSecret = errors.New("universal: the client secret is invalid")
+	// ErrInvalidState is returned by CreateAuthURL for a state which is too
+	// short or too long.
+	ErrInvalidState = fmt.Errorf("universal: state must be between %d and %d characters", minStateLength, maxStateLength)
+	// ErrInvalidUsername is returned by CreateAuthURL for an empty username.
+	ErrInvalidUsername = errors.New("universal: the username is invalid")
+	// ErrInvalidIDToken is returned, wrapped with the reason, for an
+	// id_token which fails validation.
+	ErrInvalidIDToken = errors.New("universal: invalid id_token")
+	// ErrDenied is returned, wrapped with Duo's status message, by
+	// ExchangeAuthorizationCodeFor2faResult for a valid id_token whose
+	// result is not "allow".
+	ErrDenied = errors.New("universal: the login was denied")
+)
+
+// Client makes Universal Prompt requests for one Web SDK application.
+type Client struct {
+	api      *duoapi.DuoApi
+	clientID string
+	// clientSecret is behind a pointer so that fmt never prints it.
+	clientSecret *string
+	apiHost      string
+	redirectURI  string
+	now          func() time.Time
+}
+
+// NewClient returns a Client for the application with the given client ID
+// and secret, whose users are sent back to redirectURI.  options configure
+// the underlying DuoApi, e.g. duoapi.SetTimeout, duoapi.SetProxy or
+// duoapi.SetPinnedSPKI; duoapi.SetBaseURL sends requests to a stand-in.
+//
+// Example: universal.NewClient(clientID,clientSecret,apiHost,"https://example.com/duo-callback",duoapi.SetTimeout(10*time.Second))
+func NewClient(clientID string,
+	clientSecret string,
+	apiHost string,
+	redirectURI string,
+	options ...duoapi.ApiOption) (*Client, error) {
+	if len(clientID) != clientIDLength {
+		return nil, ErrInvalidClientID
+	}
+	if len(clientSecret) != clientSecretLength {
+		return nil, ErrInvalidClientSecret
+	}
+	return &Client{
+		api:          duoapi.NewDuoApi(clientID, clientSecret, apiHost, "", options...),
+		clientID:     clientID,
+		clientSecret: &clientSecret,
+		apiHost:      apiHost,
+		redirectURI:  redirectURI,
+		now:          time.Now,
+	}, nil
+}
+
+// String describes the client without its secret.
+func (c Client) String() string {
+	return fmt.Sprintf("universal.Client{clientID: %s, clientSecret: %s, apiHost: %s}", c.clientID, duoapi.Redacted, c.apiHost)
+}
+
+// GoString is like String, for the %#v verb.
+func (c Client) GoString() string {
+	return c.String()
+}
+
+// endpoint returns the URL of uri on the real API host, which the JWTs the
+// client signs are addressed to, whatever the base URL.
+func (c *Client) endpoint(uri string) string {
+	return "https://" + c.apiHost + uri
+}
+
+// clientAssertion returns a JWT which authenticates the client to the
+// endpoint at uri.
+func (c *Client) clientAssertion(uri string) (string, error) {
+	jti, err := randomString(DefaultStateLength)
+	if err != nil {
+		return "", err
+	}
+	now := c.now()
+	return jwt.Sign(map[string]interface{}{
+		"iss": c.clientID,
+		"sub": c.clientID,
+		"aud": c.endpoint(uri),
+		"exp": now.Add(expiry).Unix(),
+		"iat": now.Unix(),
+		"jti": jti,
+	}, *c.clientSecret)
+}
+
+// withAssertion adds a client assertion for uri to each attempt of a call,
+// so that retries are not rejected as replays of an earlier jti.
+func (c *Client) withAssertion(uri string) duoapi.DuoApiOption {
+	return duoapi.AttemptParams(func(params url.Values) error {
+		assertion, err := c.clientAssertion(uri)
+		if err != nil {
+			return err
+		}
+		params.Set("client_assertion", assertion)
+		return nil
+	})
+}
+
+// HealthCheckResult models the response to a health check.
+type HealthCheckResult struct {
+	duoapi.StatResult
+	Response struct {
+		Timestamp int64 `json:"timestamp"`
+	}
+}
+
+// HealthCheck calls POST /oauth/v1/health_check, which fails if Duo is
+// unavailable or the client's keys are invalid, in which case the user
+// should not be sent to Duo.
+func (c *Client) HealthCheck() (*HealthCheckResult, error) {
+	return c.HealthCheckContext(context.Background())
+}
+
+// HealthCheckContext is like HealthCheck, but takes a context that bounds the request.
+func (c *Client) HealthCheckContext(ctx context.Context) (*HealthCheckResult, error) {
+	params := url.Values{}
+	params.Set("client_id", c.clientID)
+
+	result := &HealthCheckResult{}
+	err := c.api.CallInto(ctx, http.MethodPost, healthCheckURI, params, result,
+		duoapi.FormBody, duoapi.UseTimeout, c.withAssertion(healthCheckURI))
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GenerateState returns a random state of DefaultStateLength characters,
+// for CreateAuthURL.  The caller must keep it, e.g. in the user's session,
+// to check against the state Duo returns.
+func GenerateState() (string, error) {
+	return randomString(DefaultStateLength)
+}
+
+const randomCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func randomString(length int) (string, error) {
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = randomCharacters[int(b[i])%len(randomCharacters)]
+	}
+	return string(b), nil
+}
+
+// CreateAuthURL returns the URL to redirect username's browser to, to
+// authenticate with Duo.  The request is a JWT signed with the client
+// secret, carrying state, which Duo returns with the code, and nonce, which
+// is returned in the id_token.  nonce may be empty.
+func (c *Client) CreateAuthURL(username string, state string, nonce string) (string, error) {
+	if username == "" {
+		return "", ErrInvalidUsername
+	}
+	if len(state) < minStateLength || len(state) > maxStateLength {
+		return "", ErrInvalidState
+	}
+	claims := map[string]interface{}{
+		"scope":                  "openid",
+		"redirect_uri":           c.redirectURI,
+		"client_id":              c.clientID,
+		"iss":                    c.clientID,
+		"aud":                    c.endpoint(""),
+		"exp":                    c.now().Add(expiry).Unix(),
+		"state":                  state,
+		"response_type":          "code",
+		"duo_uname":              username,
+		"use_duo_code_attribute": true,
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	request, err := jwt.Sign(claims, *c.clientSecret)
+	if err != nil {
+		return "", err
+	}
+
+	u := c.api.URL(authorizeURI)
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", c.clientID)
+	query.Set("request", request)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// TokenError is returned when the token endpoint refuses a code.
+type TokenError struct {
+	StatusCode  int
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *TokenError) Error() string {
+	if e.Description == "" {
+		return fmt.Sprintf("universal: token request failed: HTTP %d %s", e.StatusCode, e.Code)
+	}
+	return fmt.Sprintf("universal: token request failed: HTTP %d %s: %s", e.StatusCode, e.Code, e.Description)
+}
+
+type tokenResponse struct {
+	IDToken     string `json:"id_token"`
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+// ExchangeAuthorizationCodeFor2faResult calls POST /oauth/v1/token to
+// exchange the code Duo returned in the duo_code parameter for username's
+// result, and validates the id_token holding it: its signature, issuer,
+// audience, expiry, username and, if it is not empty, the nonce given to
+// CreateAuthURL.  If Duo did not allow the login, it returns the claims
+// along with ErrDenied, so the user must only be logged in when the error
+// is nil.  The caller must separately check that the state Duo returned is
+// the one given to CreateAuthURL.
+func (c *Client) ExchangeAuthorizationCodeFor2faResult(code string, username string, nonce string) (*TokenClaims, error) {
+	return c.ExchangeAuthorizationCodeFor2faResultContext(context.Background(), code, username, nonce)
+}
+
+// ExchangeAuthorizationCodeFor2faResultContext is like
+// ExchangeAuthorizationCodeFor2faResult, but takes a context that bounds the
+// request.
+func (c *Client) ExchangeAuthorizationCodeFor2faResultContext(ctx context.Context,
+	code string,
+	username string,
+	nonce string) (*TokenClaims, error) {
+	params := url.Values{}
+	params.Set("grant_type", "authorization_code")
+	params.Set("code", code)
+	params.Set("redirect_uri", c.redirectURI)
+	params.Set("client_assertion_type", clientAssertionType)
+
+	resp, body, err := c.api.CallContext(ctx, http.MethodPost, tokenURI, params,
+		duoapi.FormBody, duoapi.UseTimeout, c.withAssertion(tokenURI))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		tokenErr := &TokenError{StatusCode: resp.StatusCode}
+		json.Unmarshal(body, tokenErr)
+		return nil, tokenErr
+	}
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, err
+	}
+	claims, err := c.validateIDToken(token.IDToken, username, nonce)
+	if err != nil {
+		return nil, err
+	}
+	if claims.AuthResult.Result != "allow" {
+		return claims, fmt.Errorf("%w: %s", ErrDenied, claims.AuthResult.StatusMsg)
+	}
+	return claims, nil
+}
@@ -0,0 +1,250 @@
+package universal
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	duoapi "github.com/duosecurity/duo_api_golang"
+	"github.com/duosecurity/duo_api_golang/duoapitest"
+	"github.com/duosecurity/duo_api_golang/internal/jwt"
+)
+
+const (
+	testClientID     = "DIXXXXXXXXXXXXXXXXXX"
+	testClientSecret = "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef"
+	testRedirectURI  = "https://example.com/duo-callback"
+)
+
+func newTestClient(t *testing.T, server *duoapitest.UniversalServer) *Client {
+	client, err := NewClient(testClientID, testClientSecret, duoapitest.DefaultHost, testRedirectURI,
+		duoapi.SetBaseURL(server.BaseURL()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// login follows the auth URL for username to the stand-in, and returns the
+// code and state it redirects back with.
+func login(t *testing.T, client *Client, username string, state string, nonce string) (string, string) {
+	authURL, err := client.CreateAuthURL(username, state, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	browser := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := browser.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("Expected a redirect, but got HTTP %d", resp.StatusCode)
+	}
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(callback.String(), testRedirectURI+"?") {
+		t.Errorf("Expected a redirect to %s, but got %s", testRedirectURI, callback)
+	}
+	return callback.Query().Get("duo_code"), callback.Query().Get("state")
+}
+
+func TestLogin(t *testing.T) {
+	server := duoapitest.NewUniversalServer(duoapitest.Credentials{IKey: testClientID, SKey: testClientSecret})
+	defer server.Close()
+	client := newTestClient(t, server)
+
+	health, err := client.HealthCheck()
+	if err != nil {
+		t.Fatalf("Unexpected health check error: %v", err)
+	}
+	if health.Stat != "OK" || health.Response.Timestamp == 0 {
+		t.Errorf("Unexpected health check result %+v", health)
+	}
+
+	state, err := GenerateState()
+	if err != nil || len(state) != DefaultStateLength {
+		t.Fatalf("Unexpected state %q, %v", state, err)
+	}
+	code, returnedState := login(t, client, "jsmith", state, "nonce-123")
+	if returnedState != state {
+		t.Errorf("Expected state %q, but got %q", state, returnedState)
+	}
+
+	claims, err := client.ExchangeAuthorizationCodeFor2faResult(code, "jsmith", "nonce-123")
+	if err != nil {
+		t.Fatalf("Unexpected exchange error: %v", err)
+	}
+	if claims.AuthResult.Result != "allow" || claims.PreferredUsername != "jsmith" || claims.Nonce != "nonce-123" {
+		t.Errorf("Unexpected claims %+v", claims)
+	}
+
+	_, err = client.ExchangeAuthorizationCodeFor2faResult(code, "jsmith", "nonce-123")
+	var tokenErr *TokenError
+	if !errors.As(err, &tokenErr) || tokenErr.Code != "invalid_grant" {
+		t.Errorf("Expected a reused code to be an invalid grant, but got %v", err)
+	}
+}
+
+func TestLoginDenied(t *testing.T) {
+	server := duoapitest.NewUniversalServer(duoapitest.Credentials{IKey: testClientID, SKey: testClientSecret})
+	defer server.Close()
+	server.SetOutcome("jsmith", duoapitest.Deny)
+	client := newTestClient(t, server)
+
+	state, _ := GenerateState()
+	code, _ := login(t, client, "jsmith", state, "")
+	claims, err := client.ExchangeAuthorizationCodeFor2faResult(code, "jsmith", "")
+	if !errors.Is(err, ErrDenied) {
+		t.Fatalf("Expected ErrDenied, but got %v", err)
+	}
+	if claims == nil || claims.AuthResult.Result != "deny" {
+		t.Errorf("Expected a denial, but got %+v", claims.AuthResult)
+	}
+
+	code, _ = login(t, client, "jsmith", state, "")
+	if _, err := client.ExchangeAuthorizationCodeFor2faResult(code, "mallory", ""); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("Expected a token for another user to be rejected, but got %v", err)
+	}
+}
+
+func TestHealthCheckFailures(t *testing.T) {
+	server := duoapitest.NewUniversalServer(duoapitest.Credentials{IKey: testClientID, SKey: testClientSecret})
+	defer server.Close()
+	server.SetHealthy(false)
+	client := newTestClient(t, server)
+	client.api = duoapi.NewDuoApi(testClientID, testClientSecret, duoapitest.DefaultHost, "",
+		duoapi.SetBaseURL(server.BaseURL()), duoapi.SetRetryPolicy(&duoapi.BackoffPolicy{}))
+
+	_, err := client.HealthCheck()
+	if e, ok := err.(*duoapi.Error); !ok || e.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected HTTP 503, but got %v", err)
+	}
+
+	server.SetHealthy(true)
+	wrongSecret, _ := NewClient(testClientID, strings.Repeat("0", clientSecretLength), duoapitest.DefaultHost, testRedirectURI,
+		duoapi.SetBaseURL(server.BaseURL()))
+	_, err = wrongSecret.HealthCheck()
+	if e, ok := err.(*duoapi.Error); !ok || e.Message != "invalid_client" {
+		t.Errorf("Expected invalid_client, but got %v", err)
+	}
+}
+
+func TestHealthCheckRetryAssertion(t *testing.T) {
+	var assertions []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assertions = append(assertions, r.PostFormValue("client_assertion"))
+		if len(assertions) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"stat": "OK", "response": {"timestamp": 1615700000}}`)
+	}))
+	defer ts.Close()
+	base, _ := url.Parse(ts.URL)
+	client, err := NewClient(testClientID, testClientSecret, duoapitest.DefaultHost, testRedirectURI,
+		duoapi.SetBaseURL(base), duoapi.SetRetryPolicy(&duoapi.BackoffPolicy{
+			InitialBackoff:   time.Millisecond,
+			Factor:           2,
+			MaxBackoff:       time.Second,
+			RetryStatusCodes: []int{http.StatusTooManyRequests},
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.HealthCheck(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// A retry resending the first assertion's jti would be a replay.
+	if len(assertions) != 2 || assertions[0] == "" || assertions[0] == assertions[1] {
+		t.Fatalf("Expected a fresh client assertion for each attempt, but got %q", assertions)
+	}
+}
+
+func TestValidateIDToken(t *testing.T) {
+	client, _ := NewClient(testClientID, testClientSecret, duoapitest.DefaultHost, testRedirectURI)
+	now := time.Unix(1700000000, 0)
+	client.now = func() time.Time { return now }
+
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":                "https://" + duoapitest.DefaultHost + "/oauth/v1/token",
+			"aud":                testClientID,
+			"exp":                now.Add(time.Minute).Unix(),
+			"iat":                now.Unix(),
+			"preferred_username": "jsmith",
+			"nonce":              "nonce-123",
+		}
+	}
+	tests := []struct {
+		name   string
+		change func(claims map[string]interface{})
+		secret string
+		ok     bool
+	}{
+		{"valid", func(map[string]interface{}) {}, testClientSecret, true},
+		{"audience list", func(c map[string]interface{}) { c["aud"] = []string{"other", testClientID} }, testClientSecret, true},
+		{"expired within leeway", func(c map[string]interface{}) { c["exp"] = now.Add(-30 * time.Second).Unix() }, testClientSecret, true},
+		{"wrong secret", func(map[string]interface{}) {}, strings.Repeat("0", clientSecretLength), false},
+		{"wrong issuer", func(c map[string]interface{}) { c["iss"] = "https://evil.example.com/oauth/v1/token" }, testClientSecret, false},
+		{"wrong audience", func(c map[string]interface{}) { c["aud"] = "DIYYYYYYYYYYYYYYYYYY" }, testClientSecret, false},
+		{"expired", func(c map[string]interface{}) { c["exp"] = now.Add(-2 * time.Minute).Unix() }, testClientSecret, false},
+		{"issued in the future", func(c map[string]interface{}) { c["iat"] = now.Add(time.Hour).Unix() }, testClientSecret, false},
+		{"wrong username", func(c map[string]interface{}) { c["preferred_username"] = "mallory" }, testClientSecret, false},
+		{"wrong nonce", func(c map[string]interface{}) { c["nonce"] = "replayed" }, testClientSecret, false},
+	}
+	for _, test := range tests {
+		claims := valid()
+		test.change(claims)
+		token, err := jwt.Sign(claims, test.secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.validateIDToken(token, "jsmith", "nonce-123")
+		if test.ok && err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+		if !test.ok && !errors.Is(err, ErrInvalidIDToken) {
+			t.Errorf("%s: expected ErrInvalidIDToken, but got %v", test.name, err)
+		}
+	}
+}
+
+func TestClientArguments(t *testing.T) {
+	if _, err := NewClient("DIXX", testClientSecret, duoapitest.DefaultHost, testRedirectURI); err != ErrInvalidClientID {
+		t.Errorf("Expected ErrInvalidClientID, but got %v", err)
+	}
+	if _, err := NewClient(testClientID, "short", duoapitest.DefaultHost, testRedirectURI); err != ErrInvalidClientSecret {
+		t.Errorf("Expected ErrInvalidClientSecret, but got %v", err)
+	}
+
+	client, _ := NewClient(testClientID, testClientSecret, duoapitest.DefaultHost, testRedirectURI)
+	if _, err := client.CreateAuthURL("jsmith", "too-short", ""); err != ErrInvalidState {
+		t.Errorf("Expected ErrInvalidState, but got %v", err)
+	}
+	if _, err := client.CreateAuthURL("", strings.Repeat("s", minStateLength), ""); err != ErrInvalidUsername {
+		t.Errorf("Expected ErrInvalidUsername, but got %v", err)
+	}
+	authURL, err := client.CreateAuthURL("jsmith", strings.Repeat("s", minStateLength), "")
+	if err != nil || !strings.HasPrefix(authURL, "https://"+duoapitest.DefaultHost+"/oauth/v1/authorize?") {
+		t.Errorf("Unexpected auth URL %q, %v", authURL, err)
+	}
+
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s", "%d"} {
+		for _, value := range []interface{}{client, *client} {
+			if out := fmt.Sprintf(verb, value); strings.Contains(out, testClientSecret) {
+				t.Errorf("%s: client secret in formatted output %q", verb, out)
+			}
+		}
+	}
+}
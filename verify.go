@@ -0,0 +1,223 @@
+package duoapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/duosecurity/duo_api_golang/internal/hmacsig"
+)
+
+// DefaultMaxDateSkew is how far a signed request's Date may be from the
+// current time unless a Verifier sets MaxDateSkew.
+const DefaultMaxDateSkew = 5 * time.Minute
+
+// Errors returned by Verifier.Verify.
+var (
+	ErrMissingSignature = errors.New("duoapi: request is not signed")
+	ErrUnknownIKey      = errors.New("duoapi: unknown integration key")
+	ErrInvalidSignature = errors.New("duoapi: invalid request signature")
+	ErrStaleDate        = errors.New("duoapi: request date is outside the allowed window")
+)
+
+// KeyLookup returns the secret key for the integration key ikey.  It returns
+// ErrUnknownIKey if ikey is not known.
+type KeyLookup func(ctx context.Context, ikey string) (string, error)
+
+// Verifier checks the signatures of requests signed the way DuoApi signs
+// them, for services which accept Duo-style signed requests themselves.
+// Both SignatureV2 and SignatureV5 signatures are accepted, except that a
+// request with a body other than form parameters must use SignatureV5,
+// since SignatureV2 does not cover it.
+type Verifier struct {
+	// Lookup returns the secret key for a request's integration key.
+	Lookup KeyLookup
+	// Host is the hostname requests must be signed for.  If empty, the
+	// request's Host is used.
+	Host string
+	// MaxDateSkew is how far a request's Date header may be from the
+	// current time.  It defaults to DefaultMaxDateSkew.
+	MaxDateSkew time.Duration
+	// MinVersion, if SignatureV5, rejects SignatureV2 signatures.
+	MinVersion SignatureVersion
+	// PathPrefix is removed from the front of request paths before they are
+	// verified.  Clients sign only the API path, so a service reached
+	// through a base URL with a path, e.g. "https://example.com/duo" given
+	// to SetBaseURL, must set it to that path, "/duo".
+	PathPrefix string
+
+	now func() time.Time
+}
+
+// Verify checks the signature of r, returning the integration key which
+// signed it.  The request's body is read, and replaced so that it may be
+// read again; bound it with http.MaxBytesReader first if it may be large.
+//
+// The path verified is that of the request line as the server received
+// it, less PathPrefix, rather than r.URL.Path, so that handlers mounted
+// behind http.StripPrefix, which rewrites r.URL.Path, still verify.
+func (v *Verifier) Verify(r *http.Request) (string, error) {
+	ikey, sig, ok := r.BasicAuth()
+	date := r.Header.Get("Date")
+	if !ok || date == "" {
+		return "", ErrMissingSignature
+	}
+	signedAt, err := time.Parse(time.RFC1123Z, date)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	now := time.Now
+	if v.now != nil {
+		now = v.now
+	}
+	maxSkew := v.MaxDateSkew
+	if maxSkew == 0 {
+		maxSkew = DefaultMaxDateSkew
+	}
+	if skew := now().Sub(signedAt); skew > maxSkew || skew < -maxSkew {
+		return "", ErrStaleDate
+	}
+
+	skey, err := v.Lookup(r.Context(), ikey)
+	if err != nil {
+		return "", err
+	}
+
+	params, body, err := signedContent(r)
+	if err != nil {
+		return "", err
+	}
+	host := v.Host
+	if host == "" {
+		host = r.Host
+	}
+	headers := make(map[string]string)
+	for name := range r.Header {
+		headers[name] = r.Header.Get(name)
+	}
+
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	path := v.signedPath(r)
+	canonV5 := canonicalizeV5(r.Method, host, path, params, date, body, headers)
+	if hmac.Equal(got, hmacsig.Sum(sha512.New, skey, canonV5)) {
+		return ikey, nil
+	}
+	if v.MinVersion < SignatureV5 && body == "" {
+		canon := canonicalize(r.Method, host, path, params, date)
+		if hmac.Equal(got, hmacsig.Sum(sha1.New, skey, canon)) {
+			return ikey, nil
+		}
+	}
+	return "", ErrInvalidSignature
+}
+
+// signedPath returns the path r was signed with: the path of its request
+// line, which http.StripPrefix leaves alone, less v.PathPrefix.
+func (v *Verifier) signedPath(r *http.Request) string {
+	path := r.URL.Path
+	if r.RequestURI != "" {
+		if u, err := url.ParseRequestURI(r.RequestURI); err == nil {
+			path = u.Path
+		}
+	}
+	if prefix := strings.TrimSuffix(v.PathPrefix, "/"); prefix != "" && strings.HasPrefix(path, prefix+"/") {
+		path = strings.TrimPrefix(path, prefix)
+	}
+	return path
+}
+
+// signedContent returns the parameters and body r was signed with: its form
+// parameters for a form encoded body, and otherwise its query parameters
+// and raw body.  A form encoded request with a query string as well is
+// rejected, since clients never send one and the signature would not
+// cover the query parameters r.Form merges in.
+func signedContent(r *http.Request) (url.Values, string, error) {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		contentType = ""
+	}
+	if contentType == "application/x-www-form-urlencoded" {
+		if r.URL.RawQuery != "" {
+			return nil, "", ErrInvalidSignature
+		}
+		if err := r.ParseForm(); err != nil {
+			return nil, "", err
+		}
+		return r.PostForm, "", nil
+	}
+	if r.Body == nil {
+		return r.URL.Query(), "", nil
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, "", err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return r.URL.Query(), string(body), nil
+}
+
+type verifiedIKeyKey struct{}
+
+// VerifiedIKey returns the integration key which signed the request whose
+// context is ctx, as recorded by Verifier.Handler.
+func VerifiedIKey(ctx context.Context) (string, bool) {
+	ikey, ok := ctx.Value(verifiedIKeyKey{}).(string)
+	return ikey, ok
+}
+
+// Handler returns a handler which calls next only for requests whose
+// signatures v accepts; VerifiedIKey reports which integration key signed
+// them.  Other requests are answered with a Duo-style JSON error: HTTP 401
+// for a missing, unknown or invalid signature and HTTP 500 if the key
+// lookup fails.
+//
+// Example: http.Handle("/api/", verifier.Handler(apiHandler))
+func (v *Verifier) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ikey, err := v.Verify(r)
+		if err != nil {
+			writeVerifyError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), verifiedIKeyKey{}, ikey)))
+	})
+}
+
+func writeVerifyError(w http.ResponseWriter, err error) {
+	body := map[string]interface{}{"stat": "FAIL"}
+	status := http.StatusUnauthorized
+	switch {
+	case errors.Is(err, ErrMissingSignature):
+		body["code"], body["message"] = 40101, "Missing request credentials"
+	case errors.Is(err, ErrUnknownIKey):
+		body["code"], body["message"] = 40102, "Invalid integration key in request credentials"
+	case errors.Is(err, ErrInvalidSignature), errors.Is(err, ErrStaleDate):
+		body["code"], body["message"] = 40103, "Invalid signature in request credentials"
+		if errors.Is(err, ErrStaleDate) {
+			body["message_detail"] = "Date is outside the allowed window"
+		}
+	default:
+		status = http.StatusInternalServerError
+		body["code"], body["message"] = 50000, "Internal server error"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
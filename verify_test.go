@@ -0,0 +1,259 @@
+package duoapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const verifyHost = "api-test.duosecurity.com"
+
+type ikeyResult struct {
+	StatResult
+	Response string
+}
+
+func testVerifier() *Verifier {
+	return &Verifier{
+		Lookup: func(ctx context.Context, ikey string) (string, error) {
+			if ikey != "ABC" {
+				return "", ErrUnknownIKey
+			}
+			return "123", nil
+		},
+		Host: verifyHost,
+	}
+}
+
+// verifiedServer returns a server which verifies requests with v, and
+// answers them with the integration key that signed them.
+func verifiedServer(t *testing.T, v *Verifier) *httptest.Server {
+	return httptest.NewServer(v.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ikey, ok := VerifiedIKey(r.Context())
+		if !ok {
+			t.Error("Expected the verified integration key in the request context")
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		fmt.Fprintf(w, `{"stat": "OK", "response": %q}`, ikey)
+	})))
+}
+
+func verifiedClient(t *testing.T, ts *httptest.Server, ikey, skey string, options ...func(*apiOptions)) *DuoApi {
+	base, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options = append(options, SetBaseURL(base))
+	return NewDuoApi(ikey, skey, verifyHost, "go-client", options...)
+}
+
+func TestVerifierAcceptsClientSignatures(t *testing.T) {
+	ts := verifiedServer(t, testVerifier())
+	defer ts.Close()
+
+	params := url.Values{"username": []string{"jsmith"}, "factor": []string{"push auto"}}
+	for _, version := range []SignatureVersion{SignatureV2, SignatureV5} {
+		duo := verifiedClient(t, ts, "ABC", "123", SetSignatureVersion(version))
+		for _, method := range []string{"GET", "POST", "DELETE"} {
+			result := &ikeyResult{}
+			if err := duo.SignedCallInto(context.Background(), method, "/auth/v2/preauth", params, result); err != nil {
+				t.Fatalf("v%d %s: unexpected error: %v", version, method, err)
+			}
+			if result.Response != "ABC" {
+				t.Errorf("v%d %s: expected the verified ikey ABC, but got %q", version, method, result.Response)
+			}
+		}
+	}
+
+	duo := verifiedClient(t, ts, "ABC", "123")
+	result := &ikeyResult{}
+	body := map[string]interface{}{"policy_name": "Test"}
+	err := duo.JSONSignedCallInto(context.Background(), "POST", "/admin/v2/policies", params, body, result)
+	if err != nil || result.Response != "ABC" {
+		t.Fatalf("Unexpected JSON call result %+v, %v", result, err)
+	}
+}
+
+func TestVerifierRejects(t *testing.T) {
+	ts := verifiedServer(t, testVerifier())
+	defer ts.Close()
+
+	tests := []struct {
+		name string
+		duo  *DuoApi
+		code int32
+	}{
+		{"unknown ikey", verifiedClient(t, ts, "XYZ", "123"), 40102},
+		{"wrong skey", verifiedClient(t, ts, "ABC", "456"), 40103},
+		{"wrong v5 skey", verifiedClient(t, ts, "ABC", "456", SetSignatureVersion(SignatureV5)), 40103},
+	}
+	for _, test := range tests {
+		err := test.duo.SignedCallInto(context.Background(), "GET", "/auth/v2/check", nil, &StatResult{})
+		e, ok := err.(*Error)
+		if !ok || e.StatusCode != http.StatusUnauthorized || e.Code != test.code {
+			t.Errorf("%s: expected HTTP 401 with code %d, but got %v", test.name, test.code, err)
+		}
+	}
+
+	duo := verifiedClient(t, ts, "ABC", "123")
+	err := duo.CallInto(context.Background(), "GET", "/auth/v2/check", nil, &StatResult{})
+	if e, ok := err.(*Error); !ok || e.Code != 40101 {
+		t.Errorf("Expected code 40101 for an unsigned request, but got %v", err)
+	}
+}
+
+func signedRequest(version SignatureVersion, method, uri string, params url.Values, body string, date time.Time) *http.Request {
+	target := "http://" + verifyHost + uri
+	if method == "GET" {
+		target += "?" + params.Encode()
+	}
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	dateHeader := date.UTC().Format(time.RFC1123Z)
+	r.Header.Set("Date", dateHeader)
+	if version == SignatureV5 {
+		r.Header.Set("Authorization", signV5("ABC", "123", method, verifyHost, uri, dateHeader, params, body, nil))
+	} else {
+		r.Header.Set("Authorization", sign("ABC", "123", method, verifyHost, uri, dateHeader, params))
+	}
+	return r
+}
+
+func TestVerifierTamperedParams(t *testing.T) {
+	v := testVerifier()
+	r := signedRequest(SignatureV2, "GET", "/auth/v2/auth", url.Values{"username": []string{"jsmith"}}, "", time.Now())
+	r.URL.RawQuery = url.Values{"username": []string{"admin"}}.Encode()
+	if _, err := v.Verify(r); err != ErrInvalidSignature {
+		t.Fatalf("Expected ErrInvalidSignature, but got %v", err)
+	}
+}
+
+func TestVerifierFormWithQuery(t *testing.T) {
+	v := testVerifier()
+	params := url.Values{"username": []string{"jsmith"}}
+	r := signedRequest(SignatureV2, "POST", "/auth/v2/auth", params, params.Encode(), time.Now())
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if _, err := v.Verify(r); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	r = signedRequest(SignatureV2, "POST", "/auth/v2/auth", params, params.Encode(), time.Now())
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.URL.RawQuery = "admin=1"
+	if _, err := v.Verify(r); err != ErrInvalidSignature {
+		t.Fatalf("Expected ErrInvalidSignature with an unsigned query, but got %v", err)
+	}
+}
+
+func TestVerifierDateWindow(t *testing.T) {
+	v := testVerifier()
+	now := time.Now()
+	v.now = func() time.Time { return now }
+
+	for _, offset := range []time.Duration{-6 * time.Minute, 6 * time.Minute} {
+		r := signedRequest(SignatureV2, "GET", "/auth/v2/check", nil, "", now.Add(offset))
+		if _, err := v.Verify(r); err != ErrStaleDate {
+			t.Errorf("Offset %v: expected ErrStaleDate, but got %v", offset, err)
+		}
+	}
+	r := signedRequest(SignatureV2, "GET", "/auth/v2/check", nil, "", now.Add(-4*time.Minute))
+	if _, err := v.Verify(r); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	v.MaxDateSkew = time.Minute
+	r = signedRequest(SignatureV2, "GET", "/auth/v2/check", nil, "", now.Add(-2*time.Minute))
+	if _, err := v.Verify(r); err != ErrStaleDate {
+		t.Errorf("Expected ErrStaleDate, but got %v", err)
+	}
+}
+
+func TestVerifierBodyRequiresV5(t *testing.T) {
+	v := testVerifier()
+	body, _ := json.Marshal(map[string]string{"policy_name": "Test"})
+
+	r := signedRequest(SignatureV2, "POST", "/admin/v2/policies", nil, string(body), time.Now())
+	if _, err := v.Verify(r); err != ErrInvalidSignature {
+		t.Fatalf("Expected a v2 signature over a JSON body to be rejected, but got %v", err)
+	}
+
+	r = signedRequest(SignatureV5, "POST", "/admin/v2/policies", nil, string(body), time.Now())
+	if _, err := v.Verify(r); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	read, _ := ioutil.ReadAll(r.Body)
+	if string(read) != string(body) {
+		t.Errorf("Expected the body to be readable after verification, but got %q", read)
+	}
+}
+
+func TestVerifierMinVersion(t *testing.T) {
+	v := testVerifier()
+	v.MinVersion = SignatureV5
+
+	r := signedRequest(SignatureV2, "GET", "/auth/v2/check", nil, "", time.Now())
+	if _, err := v.Verify(r); err != ErrInvalidSignature {
+		t.Fatalf("Expected ErrInvalidSignature, but got %v", err)
+	}
+	r = signedRequest(SignatureV5, "GET", "/auth/v2/check", nil, "", time.Now())
+	if _, err := v.Verify(r); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestVerifierLookupFailure(t *testing.T) {
+	v := testVerifier()
+	v.Lookup = func(ctx context.Context, ikey string) (string, error) {
+		return "", fmt.Errorf("database unavailable")
+	}
+	ts := verifiedServer(t, v)
+	defer ts.Close()
+
+	duo := verifiedClient(t, ts, "ABC", "123")
+	err := duo.SignedCallInto(context.Background(), "GET", "/auth/v2/check", nil, &StatResult{})
+	if e, ok := err.(*Error); !ok || e.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected HTTP 500, but got %v", err)
+	}
+}
+
+func TestVerifierPaths(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		ikey, _ := VerifiedIKey(r.Context())
+		fmt.Fprintf(w, `{"stat": "OK", "response": %q}`, ikey)
+	}
+
+	// A handler mounted behind http.StripPrefix verifies the path as sent.
+	mux := http.NewServeMux()
+	mux.Handle("/auth/", http.StripPrefix("/auth", testVerifier().Handler(http.HandlerFunc(handler))))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	result := &ikeyResult{}
+	duo := verifiedClient(t, ts, "ABC", "123")
+	if err := duo.SignedCallInto(context.Background(), "GET", "/auth/v2/check", nil, result); err != nil || result.Response != "ABC" {
+		t.Errorf("Unexpected result behind StripPrefix %+v, %v", result, err)
+	}
+
+	// Clients with a base URL path sign only the API path.
+	v := testVerifier()
+	v.PathPrefix = "/duo"
+	ts2 := httptest.NewServer(v.Handler(http.HandlerFunc(handler)))
+	defer ts2.Close()
+	base, _ := url.Parse(ts2.URL + "/duo")
+	duo = NewDuoApi("ABC", "123", verifyHost, "go-client", SetBaseURL(base))
+	result = &ikeyResult{}
+	if err := duo.SignedCallInto(context.Background(), "GET", "/auth/v2/check", nil, result); err != nil || result.Response != "ABC" {
+		t.Errorf("Unexpected result with PathPrefix %+v, %v", result, err)
+	}
+	v.PathPrefix = ""
+	if err := duo.SignedCallInto(context.Background(), "GET", "/auth/v2/check", nil, result); err == nil {
+		t.Error("Expected a base URL path to fail verification without PathPrefix")
+	}
+}